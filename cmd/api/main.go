@@ -7,6 +7,8 @@ import (
 	"github.com/canonical/specs-v2.canonical.com/config"
 	"github.com/canonical/specs-v2.canonical.com/db"
 	"github.com/canonical/specs-v2.canonical.com/handlers"
+	"github.com/canonical/specs-v2.canonical.com/specs"
+	"github.com/canonical/specs-v2.canonical.com/storage"
 )
 
 func main() {
@@ -27,6 +29,21 @@ func main() {
 	logger.Info("migrations completed successfully")
 
 	server := handlers.NewServer(logger, c, dbConn)
+	if c.VisibilityGroupsFile != "" {
+		groups, err := specs.NewStaticGroupResolver(c.VisibilityGroupsFile)
+		if err != nil {
+			logger.Error("failed to load groups", "error", err.Error())
+			os.Exit(1)
+		}
+		server.Groups = groups
+	}
+	archive, thumbnails, err := storage.NewObjectStores(c)
+	if err != nil {
+		logger.Error("failed to create object stores", "error", err.Error())
+		os.Exit(1)
+	}
+	server.Archive = archive
+	server.Thumbnails = thumbnails
 
 	err = server.Echo.Start(server.Config.GetHost())
 	if err != nil {
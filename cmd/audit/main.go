@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/canonical/specs-v2.canonical.com/config"
+	"github.com/canonical/specs-v2.canonical.com/db"
+	"github.com/canonical/specs-v2.canonical.com/specs"
+)
+
+// main implements a command that lists the specs shared outside the company domains or
+// their owning team, from the permissions recorded by the sync
+func main() {
+	var (
+		asJSON      bool
+		membersFile string
+	)
+	flag.BoolVar(&asJSON, "json", false, "print the report as JSON")
+	flag.StringVar(&membersFile, "team-members", "", "JSON file mapping teams to member emails (defaults to AUDIT_TEAM_MEMBERS_FILE)")
+	flag.Parse()
+
+	cfg := config.MustLoadConfig()
+	logger := config.SetupLogger()
+
+	dbConn, err := db.NewDB(logger, cfg)
+	if err != nil {
+		logger.Error("failed to connect to database", "error", err.Error())
+		os.Exit(1)
+	}
+
+	if membersFile == "" {
+		membersFile = cfg.AuditTeamMembersFile
+	}
+	var members specs.TeamMembers
+	if membersFile != "" {
+		members, err = specs.LoadTeamMembers(membersFile)
+		if err != nil {
+			logger.Error("failed to load team members", "error", err.Error())
+			os.Exit(1)
+		}
+	}
+
+	findings, err := specs.SharingReport(dbConn, members)
+	if err != nil {
+		logger.Error("failed to build sharing report", "error", err.Error())
+		os.Exit(1)
+	}
+
+	if asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(findings); err != nil {
+			logger.Error("failed to write report", "error", err.Error())
+			os.Exit(1)
+		}
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SPEC\tTEAM\tSHARING\tEXTERNAL\tOUTSIDE TEAM\tURL")
+	for _, finding := range findings {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			finding.SpecID,
+			finding.Team,
+			finding.SharingLevel,
+			strings.Join(finding.External, ","),
+			strings.Join(finding.OutsideTeam, ","),
+			finding.URL,
+		)
+	}
+	w.Flush()
+}
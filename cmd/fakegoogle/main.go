@@ -0,0 +1,42 @@
+package main
+
+import (
+	"flag"
+	"net/http"
+	"os"
+
+	"github.com/canonical/specs-v2.canonical.com/config"
+	"github.com/canonical/specs-v2.canonical.com/google/fake"
+)
+
+// main serves a fake Drive and Docs API from a fixtures directory. Set
+// GOOGLE_API_ENDPOINT to its address and SYNC_ROOT_FOLDER_ID to "root" to run
+// the sync and reject services against it.
+func main() {
+	var (
+		addr     string
+		fixtures string
+	)
+	flag.StringVar(&addr, "addr", "localhost:8089", "address to listen on")
+	flag.StringVar(&fixtures, "fixtures", "google/fake/testdata/specs", "fixtures directory")
+	flag.Parse()
+
+	logger := config.SetupLogger()
+
+	handler, err := fake.NewHandler(logger, fixtures)
+	if err != nil {
+		logger.Error("failed to load fixtures", "error", err.Error())
+		os.Exit(1)
+	}
+
+	logger.Info("serving fake google apis",
+		"addr", addr,
+		"fixtures", fixtures,
+		"files", len(handler.Files()),
+		"root_folder_id", fake.RootFolderID)
+
+	if err := http.ListenAndServe(addr, handler); err != nil {
+		logger.Error("failed to start server", "error", err.Error())
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/canonical/specs-v2.canonical.com/config"
+	"github.com/canonical/specs-v2.canonical.com/db"
+	"github.com/canonical/specs-v2.canonical.com/google"
+	"github.com/canonical/specs-v2.canonical.com/specs"
+)
+
+// main implements a command that runs as a daemon to rebuild the status history of
+// specs from the revisions of their Google Docs. It also supports updating the history
+// of an individual spec.
+func main() {
+	var (
+		rebuild     bool
+		googleDocID string
+	)
+	flag.BoolVar(&rebuild, "rebuild", false, "discard the recorded history and read every revision again")
+	flag.StringVar(&googleDocID, "google-doc-id", "", "update the history of a single doc (optional)")
+	flag.Parse()
+
+	cfg := config.MustLoadConfig()
+	logger := config.SetupLogger()
+
+	// Signal handling
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	historyService := initHistoryService(cfg, logger, rebuild)
+
+	if googleDocID != "" {
+		logger.Info("updating status history of a single file", "file_id", googleDocID)
+		if err := historyService.SyncHistoryByGoogleDocID(ctx, googleDocID); err != nil {
+			logger.Error("failed to sync status history", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	ticker := time.NewTicker(cfg.GetHistoryInterval())
+	defer ticker.Stop()
+
+	logger.Info("starting status history job",
+		"interval", cfg.GetHistoryInterval(),
+		"rebuild", rebuild,
+		"pid", os.Getpid())
+
+	if err := historyService.SyncAllHistories(ctx); err != nil {
+		logger.Error("status history synchronization failed", "error", err)
+	}
+	// Only the first run rebuilds, later ones read the new revisions
+	historyService.Config.Rebuild = false
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info("status history job stopped")
+			return
+		case <-ticker.C:
+			if err := historyService.SyncAllHistories(ctx); err != nil {
+				logger.Error("status history synchronization failed", "error", err)
+			}
+		}
+	}
+}
+
+// initHistoryService initializes the history service with all its dependencies
+func initHistoryService(cfg *config.Config, logger *slog.Logger, rebuild bool) *specs.HistoryService {
+	dbConn, err := db.NewDB(logger, cfg)
+	if err != nil {
+		logger.Error("failed to connect to database", "error", err.Error())
+		os.Exit(1)
+	}
+
+	if err := db.Migrate(dbConn); err != nil {
+		log.Fatal(err)
+	}
+
+	logger.Info("migrations completed successfully")
+
+	retry := google.DefaultRetryConfig()
+	retry.MaxRetries = cfg.GetGoogleMaxRetries()
+	googleDrive, err := google.NewGoogleDrive(google.Config{
+		ClientID:           cfg.GoogleClientID,
+		ClientEmail:        cfg.GoogleClientEmail,
+		PrivateKey:         cfg.GooglePrivateKey,
+		PrivateKeyID:       cfg.GooglePrivateKeyID,
+		ProjectID:          cfg.GoogleProjectID,
+		KeyFiles:           cfg.GetGoogleKeyFiles(),
+		DefaultCredentials: cfg.UseGoogleDefaultCredentials(),
+		Endpoint:           cfg.GoogleAPIEndpoint,
+		Scopes:             cfg.GetSyncGoogleDriveScopes(),
+		Retry:              retry,
+		RateLimits: google.RateLimits{
+			DriveList:   cfg.GetGoogleDriveListRate(),
+			DriveExport: cfg.GetGoogleDriveExportRate(),
+			DocsWrite:   cfg.GetGoogleDocsWriteRate(),
+		},
+		Logger: logger,
+	})
+
+	if err != nil {
+		logger.Error("failed to create google drive client", "error", err.Error())
+		os.Exit(1)
+	}
+
+	return &specs.HistoryService{
+		Logger:       logger.With("component", "specs_history"),
+		GoogleClient: googleDrive,
+		DB:           dbConn,
+		Config:       specs.HistoryConfig{Rebuild: rebuild},
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"log/slog"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
@@ -100,28 +101,66 @@ func initRejectService(cfg *config.Config, logger *slog.Logger, dryRun bool) *sp
 	logger.Info("migrations completed successfully")
 
 	// Create Google client with write access for document updates
-	googleDrive, err := google.NewGoogleDrive(google.Config{
-		ClientID:     cfg.GoogleClientID,
-		ClientEmail:  cfg.GoogleClientEmail,
-		PrivateKey:   cfg.GooglePrivateKey,
-		PrivateKeyID: cfg.GooglePrivateKeyID,
-		ProjectID:    cfg.GoogleProjectID,
-		Scopes:       cfg.GetRejectGoogleDriveScopes(),
-	})
+	retry := google.DefaultRetryConfig()
+	retry.MaxRetries = cfg.GetGoogleMaxRetries()
+	googleConfig := google.Config{
+		ClientID:           cfg.GoogleClientID,
+		ClientEmail:        cfg.GoogleClientEmail,
+		PrivateKey:         cfg.GooglePrivateKey,
+		PrivateKeyID:       cfg.GooglePrivateKeyID,
+		ProjectID:          cfg.GoogleProjectID,
+		KeyFiles:           cfg.GetGoogleKeyFiles(),
+		DefaultCredentials: cfg.UseGoogleDefaultCredentials(),
+		Endpoint:           cfg.GoogleAPIEndpoint,
+		Scopes:             cfg.GetRejectGoogleDriveScopes(),
+		Retry:              retry,
+		RateLimits: google.RateLimits{
+			DriveList:   cfg.GetGoogleDriveListRate(),
+			DriveExport: cfg.GetGoogleDriveExportRate(),
+			DocsWrite:   cfg.GetGoogleDocsWriteRate(),
+		},
+		Logger:  logger,
+		Subject: cfg.RejectGoogleSubject,
+	}
+	googleDrive, err := google.NewGoogleDrive(googleConfig)
 
 	if err != nil {
 		logger.Error("failed to create google drive client", "error", err.Error())
 		os.Exit(1)
 	}
 
+	// Docs of teams with their own account are edited as that account
+	teamClients := map[string]google.Backend{}
+	teamActors := map[string]string{}
+	for team, subject := range cfg.GetRejectTeamSubjects() {
+		teamConfig := googleConfig
+		teamConfig.Subject = subject
+		teamClient, err := google.NewGoogleDrive(teamConfig)
+		if err != nil {
+			logger.Error("failed to create google drive client", "team", team, "subject", subject, "error", err.Error())
+			os.Exit(1)
+		}
+		teamClients[team] = teamClient
+		teamActors[team] = strings.ToLower(subject)
+	}
+
+	// status changes are attributed to the account the Docs are edited as
+	actor := cfg.RejectGoogleSubject
+	if actor == "" {
+		actor = cfg.GoogleClientEmail
+	}
+
 	serviceConfig := specs.RejectConfig{
 		DryRun:          dryRun,
 		RejectThreshold: cfg.GetRejectThreshold(),
+		Actor:           strings.ToLower(actor),
+		TeamActors:      teamActors,
 	}
 
 	return &specs.RejectService{
 		Logger:       logger.With("component", "specs_reject"),
 		GoogleClient: googleDrive,
+		TeamClients:  teamClients,
 		DB:           dbConn,
 		Config:       serviceConfig,
 	}
@@ -12,6 +12,7 @@ import (
 	"github.com/canonical/specs-v2.canonical.com/db"
 	"github.com/canonical/specs-v2.canonical.com/google"
 	"github.com/canonical/specs-v2.canonical.com/specs"
+	"github.com/canonical/specs-v2.canonical.com/storage"
 )
 
 func main() {
@@ -31,13 +32,25 @@ func main() {
 
 	logger.Info("migrations completed successfully")
 
+	retry := google.DefaultRetryConfig()
+	retry.MaxRetries = c.GetGoogleMaxRetries()
 	googleDrive, err := google.NewGoogleDrive(google.Config{
-		ClientID:     c.GoogleClientID,
-		ClientEmail:  c.GoogleClientEmail,
-		PrivateKey:   c.GooglePrivateKey,
-		PrivateKeyID: c.GooglePrivateKeyID,
-		ProjectID:    c.GoogleProjectID,
-		Scopes:       c.GetSyncGoogleDriveScopes(),
+		ClientID:           c.GoogleClientID,
+		ClientEmail:        c.GoogleClientEmail,
+		PrivateKey:         c.GooglePrivateKey,
+		PrivateKeyID:       c.GooglePrivateKeyID,
+		ProjectID:          c.GoogleProjectID,
+		KeyFiles:           c.GetGoogleKeyFiles(),
+		DefaultCredentials: c.UseGoogleDefaultCredentials(),
+		Endpoint:           c.GoogleAPIEndpoint,
+		Scopes:             c.GetSyncGoogleDriveScopes(),
+		Retry:              retry,
+		RateLimits: google.RateLimits{
+			DriveList:   c.GetGoogleDriveListRate(),
+			DriveExport: c.GetGoogleDriveExportRate(),
+			DocsWrite:   c.GetGoogleDocsWriteRate(),
+		},
+		Logger: logger,
 	})
 
 	if err != nil {
@@ -45,6 +58,25 @@ func main() {
 		os.Exit(1)
 	}
 
+	syncSources, err := specs.ParseSyncSources(c.SyncSources)
+	if err != nil {
+		logger.Error("invalid sync sources", "error", err.Error())
+		os.Exit(1)
+	}
+
+	extractors, err := specs.NewExtractorRegistry(c.GetSyncFormats()...)
+	if err != nil {
+		logger.Error("invalid sync formats", "error", err.Error())
+		os.Exit(1)
+	}
+
+	labelMapping, err := specs.ParseLabelMapping(c.SyncLabelMapping)
+	if err != nil {
+		logger.Error("invalid sync label mapping", "error", err.Error())
+		os.Exit(1)
+	}
+	googleDrive.IncludeLabels = labelMapping.LabelIDs()
+
 	// signal handling
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -54,11 +86,45 @@ func main() {
 		googleDrive,
 		dbConn,
 		specs.SyncConfig{
-			RootFolderID:  "19jxxVn_3n6ZAmFl3DReEVgZjxZnlky4X",
+			RootFolderID:  c.SyncRootFolderID,
 			MaxGoroutines: 15,
+			MaxDepth:      c.GetSyncMaxDepth(),
+			TeamMapping:   c.GetSyncTeamMapping(),
+			RootTeam:      c.SyncRootTeam,
+			Sources:       syncSources,
+			Extractors:    extractors,
+			LabelMapping:  labelMapping,
+
+			WriteAppProperties:     c.IsSyncWritingAppProperties(),
+			AttributeStatusChanges: c.IsSyncAttributingStatus(),
+			AutomationEmails:       c.GetAutomationEmails(),
+
+			CompanyDomains:  c.GetCompanyDomains(),
+			ArchivePrefix:   c.S3Path,
+			ThumbnailPrefix: c.GetThumbnailPrefix(),
+
+			Incremental:      c.IsIncrementalSync(),
+			FullSyncInterval: c.GetSyncFullInterval(),
+
+			Watch: specs.WatchConfig{
+				Address:     c.GetDriveWebhookURL(),
+				Token:       c.DriveWebhookToken,
+				ChannelTTL:  c.GetDriveWebhookChannelTTL(),
+				RenewBefore: c.GetDriveWebhookRenewBefore(),
+				RetryDelay:  c.GetDriveWebhookRetryDelay(),
+				MaxAttempts: c.GetDriveWebhookMaxAttempts(),
+			},
 		},
 	)
 
+	archive, thumbnails, err := storage.NewObjectStores(c)
+	if err != nil {
+		logger.Error("failed to create object stores", "error", err.Error())
+		os.Exit(1)
+	}
+	syncService.Archive = archive
+	syncService.Thumbnails = thumbnails
+
 	go func() {
 		sigChan := make(chan os.Signal, 1)
 		signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
@@ -75,6 +141,7 @@ func main() {
 
 	logger.Info("starting sync job",
 		"interval", c.GetSyncInterval().String(),
+		"mode", c.SyncMode,
 		"pid", os.Getpid())
 
 	// Run initial sync
@@ -84,14 +151,43 @@ func main() {
 	}
 	syncService.Config.ForceSync = false
 
+	// Push notifications are queued by the API server and picked up here, while the
+	// watch channels are renewed well before they expire
+	var queueTick, renewTick <-chan time.Time
+	if c.IsDriveWebhookEnabled() {
+		if err := syncService.RenewWatchChannels(ctx); err != nil {
+			logger.Error("failed to renew watch channels", "error", err)
+		}
+
+		queueTicker := time.NewTicker(c.GetDriveWebhookQueueInterval())
+		defer queueTicker.Stop()
+		queueTick = queueTicker.C
+
+		renewTicker := time.NewTicker(c.GetDriveWebhookRenewBefore() / 2)
+		defer renewTicker.Stop()
+		renewTick = renewTicker.C
+	}
+
 	// Wait for either context cancellation or ticker
 	for {
 		select {
 		case <-ctx.Done():
 			logger.Info("sync job stopped")
 			return
+		case <-queueTick:
+			if err := syncService.ProcessQueue(ctx); err != nil {
+				logger.Error("failed to process sync queue", "error", err)
+			}
+		case <-renewTick:
+			if err := syncService.RenewWatchChannels(ctx); err != nil {
+				logger.Error("failed to renew watch channels", "error", err)
+			}
 		case <-ticker.C:
-			if err := syncService.SyncSpecs(ctx); err != nil {
+			sync := syncService.SyncSpecs
+			if c.IsIncrementalSync() {
+				sync = syncService.SyncChanges
+			}
+			if err := sync(ctx); err != nil {
 				logger.Error("sync failed", "error", err)
 			}
 		}
@@ -2,13 +2,18 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"os"
+	"path"
 	"reflect"
+	"strconv"
 	"strings"
 	"time"
 	"unicode"
 
 	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/driveactivity/v2"
+	"google.golang.org/api/drivelabels/v2"
 )
 
 type Config struct {
@@ -22,8 +27,14 @@ type Config struct {
 
 	PostgresqlDbConnectString string `env:"required"`
 
-	GooglePrivateKeyID string `env:"required"`
-	GooglePrivateKey   string `env:"required"`
+	// GooglePrivateKey and GooglePrivateKeyID are the inline service-account key, optional
+	// when GoogleKeyFiles or application default credentials are used
+	GooglePrivateKeyID string `env:""`
+	GooglePrivateKey   string `env:""`
+	// GoogleKeyFiles lists service-account JSON key files, tried in order after the inline key
+	GoogleKeyFiles string `env:""`
+	// GoogleDefaultCredentials falls back to application default credentials after the keys
+	GoogleDefaultCredentials string `env:"default:false,enums:true;false"`
 
 	GoogleOAuthClientID     string `env:"required"`
 	GoogleOAuthClientSecret string `env:"required"`
@@ -31,18 +42,105 @@ type Config struct {
 	GoogleClientID    string `env:"default:112404606310881291739"`
 	GoogleClientEmail string `env:"default:specs-reader@roadmap-270011.iam.gserviceaccount.com"`
 	GoogleProjectID   string `env:"default:roadmap-270011"`
+	// GoogleAPIEndpoint points the Drive and Docs clients at another server, e.g. cmd/fakegoogle
+	GoogleAPIEndpoint string `env:""`
+	// GoogleMaxRetries is the number of retries of Drive and Docs requests failing with transient errors
+	GoogleMaxRetries string `env:"default:5"`
+	// Sustained requests per second allowed for each Drive and Docs quota, 0 disables the limit
+	GoogleDriveListRate   string `env:"default:10"`
+	GoogleDriveExportRate string `env:"default:3"`
+	GoogleDocsWriteRate   string `env:"default:1"`
 
 	SyncInterval          string `env:"default:1h"`
+	SyncRootFolderID      string `env:"default:19jxxVn_3n6ZAmFl3DReEVgZjxZnlky4X"`
+	SyncMaxDepth          string `env:"default:5"`
+	SyncRootTeam          string `env:"default:General"`
 	SyncGoogleDriveScopes string `env:"default:readonly"`
+	SyncMode              string `env:"default:full,enums:full;incremental"`
+	SyncFullInterval      string `env:"default:24h"`
+	// SyncTeamMapping maps folder IDs or names to teams, e.g. "Archive=Engineering,1AbC=Design"
+	SyncTeamMapping string `env:""`
+	// SyncSources lists the folders and shared drives to sync instead of SyncRootFolderID,
+	// e.g. "user:1AbC,drive:0XyZ@drive", see specs.ParseSyncSources
+	SyncSources string `env:""`
+	// SyncFormats lists the file formats synced as specs: google_doc, google_sheet, docx, markdown and pdf
+	SyncFormats string `env:"default:google_doc"`
+	// SyncLabelMapping sets spec columns from Drive label fields, taking priority over the
+	// metadata table, e.g. "status=0AbC/1dEf,team=0AbC/2gHi", see specs.ParseLabelMapping
+	SyncLabelMapping string `env:""`
+	// SyncAppProperties "write" records the metadata of re-parsed specs in the app
+	// properties of their files. It needs SyncGoogleDriveScopes to allow metadata writes,
+	// e.g. "readonly,metadata". The properties are only read with "read". Either way,
+	// outside the full sync a Doc whose properties were written for its current content,
+	// e.g. by the reject service, gets its status from them without being exported.
+	SyncAppProperties string `env:"default:read,enums:read;write"`
+	// SyncStatusAttribution attributes status changes to the editor of the Doc since the
+	// previous sync found through the Drive Activity API, when there is only one, which needs the "activity" scope in
+	// SyncGoogleDriveScopes. Status changes are recorded without an actor otherwise.
+	SyncStatusAttribution string `env:"default:false,enums:true;false"`
+
+	// DriveWebhookToken enables Drive push notifications, it is sent back by Drive with
+	// every notification to authenticate it
+	DriveWebhookToken         string `env:""`
+	DriveWebhookURL           string `env:""`
+	DriveWebhookChannelTTL    string `env:"default:24h"`
+	DriveWebhookRenewBefore   string `env:"default:2h"`
+	DriveWebhookQueueInterval string `env:"default:5s"`
+	// DriveWebhookRetryDelay is how long a queued Doc that failed to sync waits before its
+	// first retry, the delay doubling with each failure. Docs are left to the next full
+	// sync after DriveWebhookMaxAttempts failures.
+	DriveWebhookRetryDelay  string `env:"default:1m"`
+	DriveWebhookMaxAttempts string `env:"default:5"`
+
+	// HistoryInterval is how often the status history is updated from new Doc revisions
+	HistoryInterval string `env:"default:24h"`
+
+	// S3Bucket enables archiving PDF snapshots of specs reaching a terminal status to
+	// S3-compatible object storage. The S3 settings follow the S3 charm integration.
+	S3Bucket    string `env:""`
+	S3Endpoint  string `env:""`
+	S3Region    string `env:"default:us-east-1"`
+	S3AccessKey string `env:""`
+	S3SecretKey string `env:""`
+	// S3Path is the prefix of the archived objects
+	S3Path            string `env:"default:specs"`
+	S3AddressingStyle string `env:"default:path,enums:path;virtual"`
+
+	// ThumbnailStorage is where the Drive thumbnails of specs are cached: on disk in
+	// ThumbnailCacheDir, in the S3 bucket under S3Path, or not at all
+	ThumbnailStorage  string `env:"default:disk,enums:disk;s3;none"`
+	ThumbnailCacheDir string `env:"default:/var/lib/specs/thumbnails"`
+
+	// VisibilityMode "enforce" only shows users the specs they can open in Drive
+	VisibilityMode string `env:"default:open,enums:open;enforce"`
+	// VisibilityGroupsFile is a JSON file mapping group emails to the emails of their
+	// members, used to resolve the groups specs are shared with
+	VisibilityGroupsFile string `env:""`
+
+	// CompanyDomains lists the email domains of the company, sharing with other domains is external
+	CompanyDomains string `env:"default:canonical.com"`
+	// AdminEmails lists the users allowed to see admin reports such as the sharing audit
+	AdminEmails string `env:""`
+	// AuditTeamMembersFile is a JSON file mapping teams to the emails of their members,
+	// used to report specs shared outside their owning team
+	AuditTeamMembersFile string `env:""`
 
 	RejectInterval          string `env:"default:24h"`
 	RejectThreshold         string `env:"default:4380h"` // 6 months
 	RejectGoogleDriveScopes string `env:"default:full"`
+	// RejectGoogleSubject is the user the reject service edits Docs as through domain-wide
+	// delegation, e.g. "specs-bot@canonical.com". The service account is used when unset.
+	RejectGoogleSubject string `env:""`
+	// RejectTeamSubjects maps teams to the user editing their Docs, e.g. "Design=design-bot@canonical.com"
+	RejectTeamSubjects string `env:""`
 }
 
 // scopeAliases maps short names to full Google Drive scope URLs
 var scopeAliases = map[string]string{
 	"readonly": drive.DriveReadonlyScope,
+	"metadata": drive.DriveMetadataScope,
+	"labels":   drivelabels.DriveLabelsReadonlyScope,
+	"activity": driveactivity.DriveActivityReadonlyScope,
 	"full":     drive.DriveScope,
 	"file":     drive.DriveFileScope,
 }
@@ -59,6 +157,48 @@ func (c *Config) GetDBDSN() string {
 	return c.PostgresqlDbConnectString
 }
 
+func (c *Config) GetGoogleKeyFiles() []string {
+	var files []string
+	for _, file := range strings.Split(c.GoogleKeyFiles, ",") {
+		if file = strings.TrimSpace(file); file != "" {
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+func (c *Config) UseGoogleDefaultCredentials() bool {
+	return c.GoogleDefaultCredentials == "true"
+}
+
+func (c *Config) GetGoogleMaxRetries() int {
+	n, err := strconv.Atoi(c.GoogleMaxRetries)
+	if err != nil {
+		panic(err)
+	}
+	return n
+}
+
+func (c *Config) GetGoogleDriveListRate() float64 {
+	return parseRate(c.GoogleDriveListRate)
+}
+
+func (c *Config) GetGoogleDriveExportRate() float64 {
+	return parseRate(c.GoogleDriveExportRate)
+}
+
+func (c *Config) GetGoogleDocsWriteRate() float64 {
+	return parseRate(c.GoogleDocsWriteRate)
+}
+
+func parseRate(rate string) float64 {
+	r, err := strconv.ParseFloat(rate, 64)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
 func (c *Config) GetSyncInterval() time.Duration {
 	d, err := time.ParseDuration(c.SyncInterval)
 	if err != nil {
@@ -67,6 +207,170 @@ func (c *Config) GetSyncInterval() time.Duration {
 	return d
 }
 
+func (c *Config) IsIncrementalSync() bool {
+	return c.SyncMode == "incremental"
+}
+
+func (c *Config) GetSyncFullInterval() time.Duration {
+	d, err := time.ParseDuration(c.SyncFullInterval)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func (c *Config) IsSyncWritingAppProperties() bool {
+	return c.SyncAppProperties == "write"
+}
+
+func (c *Config) IsSyncAttributingStatus() bool {
+	return c.SyncStatusAttribution == "true"
+}
+
+func (c *Config) IsDriveWebhookEnabled() bool {
+	return c.DriveWebhookToken != ""
+}
+
+// GetDriveWebhookURL returns the address Drive sends push notifications to,
+// defaulting to the notifications endpoint of the API server
+func (c *Config) GetDriveWebhookURL() string {
+	if c.DriveWebhookURL != "" {
+		return c.DriveWebhookURL
+	}
+	webhookURL, _ := url.JoinPath(c.CustomBaseURL, "/api/drive/notifications")
+	return webhookURL
+}
+
+func (c *Config) GetDriveWebhookChannelTTL() time.Duration {
+	d, err := time.ParseDuration(c.DriveWebhookChannelTTL)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func (c *Config) GetDriveWebhookRenewBefore() time.Duration {
+	d, err := time.ParseDuration(c.DriveWebhookRenewBefore)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func (c *Config) GetDriveWebhookQueueInterval() time.Duration {
+	d, err := time.ParseDuration(c.DriveWebhookQueueInterval)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func (c *Config) GetDriveWebhookRetryDelay() time.Duration {
+	d, err := time.ParseDuration(c.DriveWebhookRetryDelay)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func (c *Config) GetDriveWebhookMaxAttempts() int {
+	attempts, err := strconv.Atoi(c.DriveWebhookMaxAttempts)
+	if err != nil {
+		panic(err)
+	}
+	return attempts
+}
+
+func (c *Config) GetSyncMaxDepth() int {
+	depth, err := strconv.Atoi(c.SyncMaxDepth)
+	if err != nil {
+		panic(err)
+	}
+	return depth
+}
+
+func (c *Config) GetSyncFormats() []string {
+	var formats []string
+	for _, format := range strings.Split(c.SyncFormats, ",") {
+		if format = strings.TrimSpace(format); format != "" {
+			formats = append(formats, format)
+		}
+	}
+	return formats
+}
+
+// GetSyncTeamMapping parses comma-separated "folder=team" pairs
+func (c *Config) GetSyncTeamMapping() map[string]string {
+	return parseMapping(c.SyncTeamMapping)
+}
+
+// parseMapping parses comma-separated "key=value" pairs
+func parseMapping(value string) map[string]string {
+	mapping := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		key, val, ok := strings.Cut(pair, "=")
+		if !ok {
+			continue
+		}
+		mapping[strings.TrimSpace(key)] = strings.TrimSpace(val)
+	}
+	return mapping
+}
+
+func (c *Config) GetHistoryInterval() time.Duration {
+	d, err := time.ParseDuration(c.HistoryInterval)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func (c *Config) IsArchiveEnabled() bool {
+	return c.S3Bucket != ""
+}
+
+// GetThumbnailPrefix returns the prefix of the thumbnail keys, which share the bucket
+// with the spec archives when stored in S3
+func (c *Config) GetThumbnailPrefix() string {
+	if c.ThumbnailStorage == "s3" {
+		return path.Join(c.S3Path, "thumbnails")
+	}
+	return ""
+}
+
+func (c *Config) IsVisibilityEnforced() bool {
+	return c.VisibilityMode == "enforce"
+}
+
+func (c *Config) GetCompanyDomains() []string {
+	return parseList(c.CompanyDomains)
+}
+
+func (c *Config) GetAdminEmails() []string {
+	return parseList(c.AdminEmails)
+}
+
+// IsAdmin reports whether an email belongs to an admin
+func (c *Config) IsAdmin(email string) bool {
+	for _, admin := range c.GetAdminEmails() {
+		if strings.EqualFold(admin, email) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseList parses comma-separated values, dropping empty ones
+func parseList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 func (c *Config) GetRejectInterval() time.Duration {
 	d, err := time.ParseDuration(c.RejectInterval)
 	if err != nil {
@@ -91,8 +395,26 @@ func (c *Config) GetRejectGoogleDriveScopes() []string {
 	return parseScopes(c.RejectGoogleDriveScopes)
 }
 
+// GetRejectTeamSubjects parses comma-separated "team=user" pairs
+func (c *Config) GetRejectTeamSubjects() map[string]string {
+	return parseMapping(c.RejectTeamSubjects)
+}
+
+// GetAutomationEmails returns the accounts the services edit Docs as: the service
+// account and the users impersonated by the reject service
+func (c *Config) GetAutomationEmails() []string {
+	emails := []string{strings.ToLower(c.GoogleClientEmail)}
+	if c.RejectGoogleSubject != "" {
+		emails = append(emails, strings.ToLower(c.RejectGoogleSubject))
+	}
+	for _, subject := range c.GetRejectTeamSubjects() {
+		emails = append(emails, strings.ToLower(subject))
+	}
+	return emails
+}
+
 // parseScopes converts comma-separated scope names to full URLs.
-// Supports aliases (readonly, full, file) and full URLs.
+// Supports aliases (readonly, metadata, labels, activity, full, file) and full URLs.
 func parseScopes(scopesStr string) []string {
 	result := make([]string, 0)
 	for _, s := range strings.Split(scopesStr, ",") {
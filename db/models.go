@@ -8,33 +8,250 @@ import (
 )
 
 type Spec struct {
-	ID                 string         `gorm:"type:text;primaryKey"`
-	Title              *string        `gorm:"type:text"`
-	Status             *string        `gorm:"type:text"`
-	Authors            pq.StringArray `gorm:"type:text[]"`
-	Reviewers          []Reviewer     `gorm:"foreignKey:SpecID"`
-	SpecType           *string        `gorm:"type:text;column:spec_type"`
-	Team               string         `gorm:"type:text;not null"`
-	GoogleDocID        string         `gorm:"type:text;not null;column:google_doc_id"`
-	GoogleDocName      string         `gorm:"type:text;not null;column:google_doc_name"`
-	GoogleDocURL       string         `gorm:"type:text;not null;column:google_doc_url"`
-	GoogleDocCreatedAt time.Time      `gorm:"not null;column:google_doc_created_at"`
-	GoogleDocUpdatedAt time.Time      `gorm:"not null;column:google_doc_updated_at"`
-	CreatedAt          time.Time      `gorm:"not null;default:CURRENT_TIMESTAMP"`
-	UpdatedAt          time.Time      `gorm:"not null;default:CURRENT_TIMESTAMP"`
-	SyncedAt           time.Time      `gorm:"not null;default:CURRENT_TIMESTAMP"`
+	ID      string         `gorm:"type:text;primaryKey"`
+	Title   *string        `gorm:"type:text"`
+	Status  *string        `gorm:"type:text"`
+	Authors pq.StringArray `gorm:"type:text[]"`
+	// AuthorPeople are the authors with their email, stored in spec_authors
+	AuthorPeople       []Person   `gorm:"-"`
+	Reviewers          []Reviewer `gorm:"foreignKey:SpecID"`
+	SpecType           *string    `gorm:"type:text;column:spec_type"`
+	Team               string     `gorm:"type:text;not null"`
+	GoogleDocID        string     `gorm:"type:text;not null;column:google_doc_id"`
+	GoogleDocName      string     `gorm:"type:text;not null;column:google_doc_name"`
+	GoogleDocURL       string     `gorm:"type:text;not null;column:google_doc_url"`
+	GoogleDocCreatedAt time.Time  `gorm:"not null;column:google_doc_created_at"`
+	GoogleDocUpdatedAt time.Time  `gorm:"not null;column:google_doc_updated_at"`
+	// SourceFormat is the format of the Drive file the spec was read from, e.g. google_doc or pdf
+	SourceFormat string `gorm:"type:text;not null;default:'google_doc'"`
+	// SharingLevel is the widest audience the file is shared with, e.g. domain or public
+	SharingLevel string `gorm:"type:text;not null;default:'unknown'"`
+	// ThumbnailKey is the key of the cached Drive thumbnail of the file, empty without one
+	ThumbnailKey string    `gorm:"type:text"`
+	CreatedAt    time.Time `gorm:"not null;default:CURRENT_TIMESTAMP"`
+	UpdatedAt    time.Time `gorm:"not null;default:CURRENT_TIMESTAMP"`
+	SyncedAt     time.Time `gorm:"not null;default:CURRENT_TIMESTAMP"`
+	// TemplateVersion is the version of the metadata table template the spec was read
+	// from, empty for formats without a table such as front matter
+	TemplateVersion string `gorm:"type:text"`
+	// DeclaredCreatedAt is the creation date written in the metadata table, nil when it is
+	// missing or unreadable. Docs copied from a template are created long after it.
+	DeclaredCreatedAt *time.Time `gorm:"column:declared_created_at"`
 }
 
 type Reviewer struct {
 	ID     string  `gorm:"type:text;primaryKey"`
 	SpecID string  `gorm:"type:text;index"`
 	Name   *string `gorm:"type:text"`
+	// Email is set when the reviewer was given as a person chip or an email address
+	Email  *string `gorm:"type:text"`
 	Status *string `gorm:"type:text"`
+	// ReviewedAt is the date of the review written in the metadata table, nil when it is
+	// missing or unreadable
+	ReviewedAt *time.Time
+	// Position is the rank of the reviewer in the metadata table
+	Position int `gorm:"not null;default:0"`
+}
+
+// Person is an author or reviewer of specs, identified by their email when the metadata
+// table gave one and by their name otherwise
+type Person struct {
+	// ID is the lowercased email, or "name:" followed by the lowercased name for people
+	// known by name only
+	ID        string    `gorm:"type:text;primaryKey"`
+	Email     string    `gorm:"type:text;index"`
+	Name      string    `gorm:"type:text"`
+	UpdatedAt time.Time `gorm:"not null;default:CURRENT_TIMESTAMP"`
+}
+
+func (Person) TableName() string {
+	return "people"
+}
+
+// SpecAuthor links a spec to one of its authors
+type SpecAuthor struct {
+	SpecID   string `gorm:"type:text;primaryKey"`
+	PersonID string `gorm:"type:text;primaryKey;index"`
+	// Position is the rank of the author in the metadata table
+	Position int `gorm:"not null;default:0"`
+}
+
+// SpecReviewer links a spec to one of its reviewers
+type SpecReviewer struct {
+	SpecID   string `gorm:"type:text;primaryKey"`
+	PersonID string `gorm:"type:text;primaryKey;index"`
+	Status   string `gorm:"type:text"`
+}
+
+// SyncState persists the progress of the incremental sync between runs
+type SyncState struct {
+	ID string `gorm:"type:text;primaryKey"`
+	// PageToken is the Drive Changes API token to resume listing changes from
+	PageToken string `gorm:"type:text;not null"`
+	// FullSyncAt is when the last full reconciliation started
+	FullSyncAt time.Time `gorm:"not null"`
+	UpdatedAt  time.Time `gorm:"not null;default:CURRENT_TIMESTAMP"`
+}
+
+// Kinds of Drive watch channels
+const (
+	WatchChannelKindFile    = "file"
+	WatchChannelKindChanges = "changes"
+)
+
+// WatchChannel is a Drive push notification channel opened by the sync service
+type WatchChannel struct {
+	ID         string `gorm:"type:text;primaryKey"`
+	ResourceID string `gorm:"type:text;not null"`
+	// Kind is either a single file or the changes feed
+	Kind string `gorm:"type:text;not null"`
+	// GoogleDocID is the watched Doc for file channels
+	GoogleDocID string    `gorm:"type:text;index;column:google_doc_id"`
+	Expiration  time.Time `gorm:"not null"`
+	CreatedAt   time.Time `gorm:"not null;default:CURRENT_TIMESTAMP"`
+}
+
+// SyncQueueItem is a Doc waiting to be re-parsed after a push notification. An empty
+// GoogleDocID asks for the changes feed to be processed instead.
+type SyncQueueItem struct {
+	ID            string    `gorm:"type:text;primaryKey"`
+	GoogleDocID   string    `gorm:"type:text;column:google_doc_id"`
+	ResourceState string    `gorm:"type:text"`
+	CreatedAt     time.Time `gorm:"not null;default:CURRENT_TIMESTAMP"`
+	// Attempts counts the failed attempts to sync the item, which is not retried before
+	// NextAttemptAt
+	Attempts      int       `gorm:"not null;default:0"`
+	NextAttemptAt time.Time `gorm:"not null;default:CURRENT_TIMESTAMP;index"`
+}
+
+// SpecStatusHistory is a period during which a spec had a given status, reconstructed
+// from the revision history of its Google Doc
+type SpecStatusHistory struct {
+	ID     string `gorm:"type:text;primaryKey"`
+	SpecID string `gorm:"type:text;not null;index"`
+	Status string `gorm:"type:text;not null"`
+	// StartedAt is when the first revision with the status was saved
+	StartedAt time.Time `gorm:"not null"`
+	// EndedAt is when the status changed, nil for the current status
+	EndedAt *time.Time
+	// RevisionID is the first revision with the status, and ChangedBy its author
+	RevisionID string `gorm:"type:text;not null"`
+	ChangedBy  string `gorm:"type:text"`
+	// CheckedRevisionID and CheckedAt are the last revision found with the status, later
+	// runs only export newer revisions
+	CheckedRevisionID string    `gorm:"type:text;not null"`
+	CheckedAt         time.Time `gorm:"not null"`
+}
+
+func (SpecStatusHistory) TableName() string {
+	return "spec_status_history"
+}
+
+// SpecPermission is a Drive permission on the file of a spec
+type SpecPermission struct {
+	ID     string `gorm:"type:text;primaryKey"`
+	SpecID string `gorm:"type:text;not null;index"`
+	// Type is one of user, group, domain or anyone, Role one of the Drive roles
+	Type        string `gorm:"type:text;not null"`
+	Role        string `gorm:"type:text;not null"`
+	Email       string `gorm:"type:text"`
+	Domain      string `gorm:"type:text"`
+	DisplayName string `gorm:"type:text"`
+	// External is set for grantees outside the company domains, anyone included
+	External bool `gorm:"not null;default:false"`
+}
+
+// SpecCommentActivity summarises the Drive comments on the file of a spec
+type SpecCommentActivity struct {
+	SpecID string `gorm:"type:text;primaryKey"`
+	// OpenComments and ResolvedComments count the comment threads, replies excluded
+	OpenComments     int `gorm:"not null;default:0"`
+	ResolvedComments int `gorm:"not null;default:0"`
+	// LastCommentAt is when the latest comment or reply was posted, nil without comments
+	LastCommentAt *time.Time
+	SyncedAt      time.Time `gorm:"not null;default:CURRENT_TIMESTAMP"`
+}
+
+func (SpecCommentActivity) TableName() string {
+	return "spec_comment_activity"
+}
+
+// SpecCommenter is someone who commented on or replied to a comment on a spec
+type SpecCommenter struct {
+	ID     string `gorm:"type:text;primaryKey"`
+	SpecID string `gorm:"type:text;not null;index"`
+	Name   string `gorm:"type:text"`
+	Email  string `gorm:"type:text;index"`
+	// Comments counts the comments and replies they posted
+	Comments      int       `gorm:"not null;default:0"`
+	LastCommentAt time.Time `gorm:"not null"`
+}
+
+// SpecArchive is a PDF snapshot of a spec taken when it reached a terminal status. It
+// is kept after the spec is removed from Drive.
+type SpecArchive struct {
+	ID     string `gorm:"type:text;primaryKey"`
+	SpecID string `gorm:"type:text;not null;index"`
+	Status string `gorm:"type:text;not null"`
+	// ObjectKey is the key of the PDF in the archive bucket
+	ObjectKey          string    `gorm:"type:text;not null"`
+	Size               int64     `gorm:"not null"`
+	GoogleDocID        string    `gorm:"type:text;not null;column:google_doc_id"`
+	GoogleDocUpdatedAt time.Time `gorm:"not null;column:google_doc_updated_at"`
+	ArchivedAt         time.Time `gorm:"not null"`
+}
+
+// SpecArchiveReader is a grantee who could read the file of a spec when it was
+// archived, so the archive stays visible to them once the spec is removed from Drive
+type SpecArchiveReader struct {
+	ArchiveID string `gorm:"type:text;primaryKey"`
+	// PermissionID is the ID of the spec_permissions row the reader was copied from
+	PermissionID string `gorm:"type:text;primaryKey"`
+	// Type is one of user, group, domain or anyone
+	Type   string `gorm:"type:text;not null"`
+	Email  string `gorm:"type:text"`
+	Domain string `gorm:"type:text"`
+}
+
+// SpecStatusChange is a change of the status of a spec seen by the sync or made by the
+// reject job, attributed to the likely author of the edit through the Drive Activity API
+type SpecStatusChange struct {
+	ID         string `gorm:"type:text;primaryKey"`
+	SpecID     string `gorm:"type:text;not null;index"`
+	FromStatus string `gorm:"type:text"`
+	ToStatus   string `gorm:"type:text;not null"`
+	// ChangedAt is the time of the edit the change is attributed to, or the modified
+	// time of the Doc when no edit was found
+	ChangedAt time.Time `gorm:"not null"`
+	// Actor is the email of the author of the edit, or their Drive Activity person name
+	// when it could not be resolved. It is empty when the author is unknown.
+	Actor     string `gorm:"type:text"`
+	ActorName string `gorm:"type:text"`
+	// Automated is set for the changes made by the reject job or another automation
+	// account rather than by a person
+	Automated  bool      `gorm:"not null;default:false"`
+	DetectedAt time.Time `gorm:"not null"`
+}
+
+// SpecPropertyConflict is a value the metadata table of a spec disagrees with the app
+// properties of its file on, as found by the last parse of the spec
+type SpecPropertyConflict struct {
+	ID     string `gorm:"type:text;primaryKey"`
+	SpecID string `gorm:"type:text;not null;index"`
+	// Key is the app property, e.g. "status"
+	Key           string `gorm:"type:text;not null"`
+	TableValue    string `gorm:"type:text"`
+	PropertyValue string `gorm:"type:text"`
+	// LastAction is the automation action that last wrote the app properties, if any
+	LastAction string    `gorm:"type:text"`
+	DetectedAt time.Time `gorm:"not null"`
 }
 
 func Migrate(db *gorm.DB) error {
 	// Create the specs table
-	if err := db.AutoMigrate(&Spec{}, &Reviewer{}); err != nil {
+	if err := db.AutoMigrate(&Spec{}, &Reviewer{}, &SyncState{}, &WatchChannel{}, &SyncQueueItem{},
+		&SpecStatusHistory{}, &SpecCommentActivity{}, &SpecCommenter{}, &SpecPermission{}, &SpecArchive{},
+		&SpecArchiveReader{}, &SpecPropertyConflict{}, &SpecStatusChange{}, &Person{}, &SpecAuthor{}, &SpecReviewer{}); err != nil {
 		return err
 	}
 
@@ -63,5 +280,19 @@ func Rollback(db *gorm.DB) error {
         DROP FUNCTION IF EXISTS update_specs_updated_at_column();
         DROP TABLE IF EXISTS specs;
         DROP TABLE IF EXISTS reviewers;
+        DROP TABLE IF EXISTS sync_states;
+        DROP TABLE IF EXISTS watch_channels;
+        DROP TABLE IF EXISTS sync_queue_items;
+        DROP TABLE IF EXISTS spec_status_history;
+        DROP TABLE IF EXISTS spec_comment_activity;
+        DROP TABLE IF EXISTS spec_commenters;
+        DROP TABLE IF EXISTS spec_permissions;
+        DROP TABLE IF EXISTS spec_archives;
+        DROP TABLE IF EXISTS spec_archive_readers;
+        DROP TABLE IF EXISTS spec_property_conflicts;
+        DROP TABLE IF EXISTS spec_status_changes;
+        DROP TABLE IF EXISTS spec_authors;
+        DROP TABLE IF EXISTS spec_reviewers;
+        DROP TABLE IF EXISTS people;
     `).Error
 }
@@ -0,0 +1,68 @@
+package google
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"google.golang.org/api/driveactivity/v2"
+)
+
+// QueryEditActivity returns the edits of a file made after the given time and until the
+// second one, newest first. Activities are not consolidated, so each one has the actor
+// of a single edit.
+func (g *Google) QueryEditActivity(
+	ctx context.Context,
+	fileID string,
+	after, until time.Time,
+) ([]*driveactivity.DriveActivity, error) {
+	req := &driveactivity.QueryDriveActivityRequest{
+		ItemName: "items/" + fileID,
+		Filter: fmt.Sprintf(`time > %q AND time <= %q AND detail.action_detail_case:EDIT`,
+			after.UTC().Format(time.RFC3339), until.UTC().Format(time.RFC3339)),
+	}
+
+	var activities []*driveactivity.DriveActivity
+	err := g.ActivityService.Activity.Query(req).Pages(ctx, func(resp *driveactivity.QueryDriveActivityResponse) error {
+		activities = append(activities, resp.Activities...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return activities, nil
+}
+
+// ActivityTime returns the time of an activity, the end of its range for the ones
+// spanning a period
+func ActivityTime(activity *driveactivity.DriveActivity) (time.Time, error) {
+	timestamp := activity.Timestamp
+	if timestamp == "" && activity.TimeRange != nil {
+		timestamp = activity.TimeRange.EndTime
+	}
+	return time.Parse(time.RFC3339, timestamp)
+}
+
+// ActivityActor returns the person name ("people/<ID>") of the user behind an activity,
+// the impersonated user for edits made through domain-wide delegation, and whether it
+// is the user making the query. The person name is empty for anonymous, deleted and
+// system actors.
+func ActivityActor(activity *driveactivity.DriveActivity) (personName string, isCurrentUser bool) {
+	for _, actor := range activity.Actors {
+		user := actor.User
+		if actor.Impersonation != nil {
+			user = actor.Impersonation.ImpersonatedUser
+		}
+		if user != nil && user.KnownUser != nil {
+			return user.KnownUser.PersonName, user.KnownUser.IsCurrentUser
+		}
+	}
+	return "", false
+}
+
+// PersonPermissionID returns the ID of a person name, which is also the ID of the Drive
+// permissions granted to that user
+func PersonPermissionID(personName string) string {
+	return strings.TrimPrefix(personName, "people/")
+}
@@ -0,0 +1,98 @@
+package google
+
+import (
+	"context"
+
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+)
+
+// ChangeResult represents a single change from the Drive Changes API with potential error.
+// The last result of a listing carries the NewStartPageToken to resume from next time.
+type ChangeResult struct {
+	Change            *drive.Change
+	NewStartPageToken string
+	Err               error
+}
+
+// GetStartPageToken returns the page token marking the current state of the Drive,
+// used to list future changes
+func (g *Google) GetStartPageToken(ctx context.Context) (string, error) {
+	token, err := g.DriveService.Changes.GetStartPageToken().
+		Context(ctx).
+		SupportsAllDrives(true).
+		Do()
+	if err != nil {
+		return "", err
+	}
+	return token.StartPageToken, nil
+}
+
+// ListChangesChannel streams the changes made since the provided page token through a channel.
+// Removed files and files in shared drives are included. Cancelling the context stops the
+// listing, so callers leaving before the new start page token cancel it rather than drain
+// the channel.
+func (g *Google) ListChangesChannel(ctx context.Context, pageToken string) <-chan ChangeResult {
+	resultChan := make(chan ChangeResult)
+
+	shortcutFields := NewFieldBuilder().
+		SubFields(FieldShortcutDetails, FieldTargetID, FieldTargetMimeType).
+		Build()
+	fileFields := NewFieldBuilder().
+		SubFields(FieldFile, append(documentFields, shortcutFields)...).
+		Build()
+	fields := NewFieldBuilder().
+		Pagination().
+		AddField(FieldNewStartPageToken).
+		SubFields(FieldChanges, FieldChangeType, FieldRemoved, FieldFileID, fileFields).
+		Build()
+
+	go func() {
+		defer close(resultChan)
+
+		// send reports whether the result was received before the context was cancelled
+		send := func(result ChangeResult) bool {
+			select {
+			case resultChan <- result:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		for {
+			if ctx.Err() != nil {
+				return
+			}
+
+			call := g.DriveService.Changes.List(pageToken).
+				Context(ctx).
+				Fields(googleapi.Field(fields)).
+				IncludeRemoved(true).
+				SupportsAllDrives(true).
+				IncludeItemsFromAllDrives(true)
+			if len(g.IncludeLabels) > 0 {
+				call = call.IncludeLabels(g.includeLabels())
+			}
+			changeList, err := call.Do()
+			if err != nil {
+				send(ChangeResult{Err: err})
+				return
+			}
+
+			for _, change := range changeList.Changes {
+				if !send(ChangeResult{Change: change}) {
+					return
+				}
+			}
+
+			if changeList.NewStartPageToken != "" {
+				send(ChangeResult{NewStartPageToken: changeList.NewStartPageToken})
+				return
+			}
+			pageToken = changeList.NextPageToken
+		}
+	}()
+
+	return resultChan
+}
@@ -2,20 +2,63 @@ package google
 
 import (
 	"context"
+	"log/slog"
 	"net/http"
+	"strings"
+	"time"
 
 	"golang.org/x/oauth2"
-	"golang.org/x/oauth2/google"
-	"golang.org/x/oauth2/jwt"
 	"google.golang.org/api/docs/v1"
 	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/driveactivity/v2"
+	"google.golang.org/api/drivelabels/v2"
 	"google.golang.org/api/option"
 )
 
+// Backend defines the Drive and Docs operations used by the sync and reject services.
+// It is implemented by Google, which can be pointed at the real APIs or at the
+// fake server in the google/fake package.
+type Backend interface {
+	ListFilesChannel(ctx context.Context, opts QueryOptions) <-chan FileResult
+	GetSubFoldersChannel(ctx context.Context, folderID string) <-chan FileResult
+	GetFilesInFolderChannel(ctx context.Context, folderID string) <-chan FileResult
+	GetFolderChildrenChannel(ctx context.Context, folderID, driveID string, mimeTypes ...string) <-chan FileResult
+	GetFile(ctx context.Context, fileID string) (*drive.File, error)
+	LabelChoices(ctx context.Context, labelID string) (map[string]map[string]string, error)
+	UpdateAppProperties(ctx context.Context, fileID string, properties map[string]string, modifiedTime string) (*drive.File, error)
+	ListPermissions(ctx context.Context, fileID string) ([]*drive.Permission, error)
+	DownloadFile(ctx context.Context, fileID string) ([]byte, error)
+	DownloadThumbnail(ctx context.Context, link string) ([]byte, error)
+	GetDrive(ctx context.Context, driveID string) (*drive.Drive, error)
+	GetStartPageToken(ctx context.Context) (string, error)
+	ListChangesChannel(ctx context.Context, pageToken string) <-chan ChangeResult
+	ListRevisionsChannel(ctx context.Context, fileID string) <-chan RevisionResult
+	QueryEditActivity(ctx context.Context, fileID string, after, until time.Time) ([]*driveactivity.DriveActivity, error)
+	RevisionMetadataTable(ctx context.Context, revision *drive.Revision) ([][]string, error)
+	ListCommentsChannel(ctx context.Context, fileID string) <-chan CommentResult
+	WatchFile(ctx context.Context, fileID string, req WatchRequest) (*drive.Channel, error)
+	WatchChanges(ctx context.Context, pageToken string, req WatchRequest) (*drive.Channel, error)
+	StopChannel(ctx context.Context, channelID, resourceID string) error
+	ExportFile(ctx context.Context, fileID string, format string) (string, error)
+	DocumentMetadataTable(ctx context.Context, fileID string) ([][]string, error)
+	DocumentTable(ctx context.Context, docID string) (*Table, error)
+	GetDocument(ctx context.Context, docID string) (*docs.Document, error)
+	BatchUpdateDocument(ctx context.Context, docID string, req *docs.BatchUpdateDocumentRequest) (*docs.BatchUpdateDocumentResponse, error)
+}
+
+// Ensure Google implements Backend
+var _ Backend = (*Google)(nil)
+
 type Google struct {
-	Client       *http.Client
-	DriveService *drive.Service
-	DocsService  *docs.Service
+	Client        *http.Client
+	DriveService  *drive.Service
+	DocsService   *docs.Service
+	LabelsService *drivelabels.Service
+	// ActivityService queries the Drive Activity API, which needs the
+	// drive.activity.readonly scope
+	ActivityService *driveactivity.Service
+	// IncludeLabels are the IDs of the Drive labels returned in the labelInfo of files
+	IncludeLabels []string
 }
 
 type Config struct {
@@ -24,28 +67,76 @@ type Config struct {
 	PrivateKey   string
 	PrivateKeyID string
 	ProjectID    string
-	Scopes       []string
+	// KeyFiles are service-account JSON key files, tried after PrivateKey in the given
+	// order. Listing the new key before the old one rolls a rotated key in without downtime.
+	KeyFiles []string
+	// DefaultCredentials falls back to application default credentials after the keys.
+	// They are always used when no key is configured.
+	DefaultCredentials bool
+	// Subject is the user the service account impersonates through domain-wide
+	// delegation, so edits are attributed to it. The service account acts as itself
+	// when unset.
+	Subject string
+	Scopes  []string
+	// Endpoint overrides the base URL of the Drive and Docs APIs, e.g. to use the fake
+	// server from the google/fake package. Requests are not authenticated when set.
+	Endpoint string
+	// Retry controls retries of failed requests, DefaultRetryConfig is used when unset
+	Retry RetryConfig
+	// RateLimits throttles requests per API quota
+	RateLimits RateLimits
+	// Logger reports retries and throttling, slog.Default is used when unset
+	Logger *slog.Logger
+}
+
+// httpClient wraps the transport of the given client with retries and rate limiting
+func (c Config) httpClient(client *http.Client) *http.Client {
+	retry := c.Retry
+	if retry == (RetryConfig{}) {
+		retry = DefaultRetryConfig()
+	}
+	return &http.Client{
+		Transport: newTransport(client.Transport, c.Logger, retry, c.RateLimits),
+		Timeout:   client.Timeout,
+	}
 }
 
 // NewGoogleDrive creates a new GoogleDrive client
 func NewGoogleDrive(config Config) (*Google, error) {
-	jwtConfig := &jwt.Config{
-		Email:        config.ClientEmail,
-		PrivateKey:   []byte(config.PrivateKey),
-		PrivateKeyID: config.PrivateKeyID,
-		Scopes:       config.Scopes,
-		TokenURL:     google.JWTTokenURL,
+	if config.Endpoint != "" {
+		return NewGoogleWithEndpoint(context.Background(), config.httpClient(http.DefaultClient), config.Endpoint)
+	}
+
+	ctx := context.Background()
+	creds, err := config.credentials(ctx)
+	if err != nil {
+		return nil, err
+	}
+	logger := config.logger()
+	logKeyAge(ctx, logger, creds)
+	if config.Subject != "" {
+		logger.Info("impersonating user through domain-wide delegation", "subject", config.Subject)
 	}
 
-	tokenSource := jwtConfig.TokenSource(context.Background())
-	client := oauth2.NewClient(context.Background(), tokenSource)
-	driveService, err := drive.NewService(context.Background(), option.WithHTTPClient(client))
+	tokenSource := oauth2.ReuseTokenSource(nil, &fallbackTokenSource{logger: logger, creds: creds})
+	client := config.httpClient(oauth2.NewClient(ctx, tokenSource))
+	driveService, err := drive.NewService(ctx, option.WithHTTPClient(client))
 
 	if err != nil {
 		return nil, err
 	}
 
-	docsService, err := docs.NewService(context.Background(), option.WithHTTPClient(client))
+	docsService, err := docs.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, err
+	}
+
+	labelsService, err := drivelabels.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, err
+	}
+
+	activityService, err := driveactivity.NewService(ctx, option.WithHTTPClient(client))
 	if err != nil {
 		return nil, err
 	}
@@ -58,16 +149,95 @@ func NewGoogleDrive(config Config) (*Google, error) {
 	}
 
 	return &Google{
-		Client:       client,
-		DriveService: driveService,
-		DocsService:  docsService,
+		Client:          client,
+		DriveService:    driveService,
+		DocsService:     docsService,
+		LabelsService:   labelsService,
+		ActivityService: activityService,
 	}, nil
 }
 
+// NewGoogleWithEndpoint creates a client that sends Drive and Docs requests to the given
+// base URL using the provided HTTP client. The Drive API is expected under "/drive/v3/",
+// the Docs API under "/v1/", and the Drive Labels and Drive Activity APIs under "/v2/",
+// mirroring the real Google hosts.
+func NewGoogleWithEndpoint(ctx context.Context, client *http.Client, endpoint string) (*Google, error) {
+	endpoint = strings.TrimSuffix(endpoint, "/")
+
+	driveService, err := drive.NewService(ctx,
+		option.WithHTTPClient(client),
+		option.WithEndpoint(endpoint+"/drive/v3/"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	docsService, err := docs.NewService(ctx,
+		option.WithHTTPClient(client),
+		option.WithEndpoint(endpoint+"/"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	labelsService, err := drivelabels.NewService(ctx,
+		option.WithHTTPClient(client),
+		option.WithEndpoint(endpoint+"/"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	activityService, err := driveactivity.NewService(ctx,
+		option.WithHTTPClient(client),
+		option.WithEndpoint(endpoint+"/"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Google{
+		Client:          client,
+		DriveService:    driveService,
+		DocsService:     docsService,
+		LabelsService:   labelsService,
+		ActivityService: activityService,
+	}, nil
+}
+
+// GetDocument fetches the Docs API representation of a document with the content of
+// all its tabs, see DocumentTabs. The body of the document is then empty.
+func (g *Google) GetDocument(ctx context.Context, docID string) (*docs.Document, error) {
+	return g.DocsService.Documents.Get(docID).IncludeTabsContent(true).Context(ctx).Do()
+}
+
+// BatchUpdateDocument applies a batch of update requests to a document
+func (g *Google) BatchUpdateDocument(
+	ctx context.Context,
+	docID string,
+	req *docs.BatchUpdateDocumentRequest,
+) (*docs.BatchUpdateDocumentResponse, error) {
+	return g.DocsService.Documents.BatchUpdate(docID, req).Context(ctx).Do()
+}
+
+// Corpora searched by a files listing
+const (
+	// CorporaUser covers the files owned by or shared with the user
+	CorporaUser = "user"
+	// CorporaDrive covers the files of the single shared drive set in DriveID
+	CorporaDrive = "drive"
+	// CorporaAllDrives covers the user corpus and every shared drive the user is a member of
+	CorporaAllDrives = "allDrives"
+)
+
 // QueryOptions defines the options for querying Drive resources
 type QueryOptions struct {
 	Query                     string
 	Fields                    string
 	SupportsAllDrives         bool
 	IncludeItemsFromAllDrives bool
+	// Corpora is one of the Corpora constants, the Drive API defaults to CorporaUser
+	Corpora string
+	// DriveID is the shared drive to search when Corpora is CorporaDrive
+	DriveID string
 }
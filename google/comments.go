@@ -0,0 +1,75 @@
+package google
+
+import (
+	"context"
+
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+)
+
+// commentsPageSize is the largest page size accepted by comments.list
+const commentsPageSize = 100
+
+// CommentResult represents a single comment of a file with potential error
+type CommentResult struct {
+	Comment *drive.Comment
+	Err     error
+}
+
+// ListCommentsChannel streams the comments of a file, with their replies, through a
+// channel. Deleted comments are left out.
+func (g *Google) ListCommentsChannel(ctx context.Context, fileID string) <-chan CommentResult {
+	resultChan := make(chan CommentResult)
+
+	authorFields := NewFieldBuilder().
+		SubFields(FieldAuthor, FieldDisplayName, FieldEmailAddress).
+		Build()
+	replyFields := NewFieldBuilder().
+		SubFields(FieldReplies, FieldID, FieldCreatedTime, FieldDeleted, authorFields).
+		Build()
+	fields := NewFieldBuilder().
+		Pagination().
+		SubFields(FieldComments, FieldID, FieldCreatedTime, FieldResolved, authorFields, replyFields).
+		Build()
+
+	go func() {
+		defer close(resultChan)
+
+		pageToken := ""
+		for {
+			select {
+			case <-ctx.Done():
+				resultChan <- CommentResult{Err: ctx.Err()}
+				return
+			default:
+			}
+
+			commentList, err := g.DriveService.Comments.List(fileID).
+				Context(ctx).
+				Fields(googleapi.Field(fields)).
+				PageSize(commentsPageSize).
+				PageToken(pageToken).
+				Do()
+			if err != nil {
+				resultChan <- CommentResult{Err: err}
+				return
+			}
+
+			for _, comment := range commentList.Comments {
+				select {
+				case resultChan <- CommentResult{Comment: comment}:
+				case <-ctx.Done():
+					resultChan <- CommentResult{Err: ctx.Err()}
+					return
+				}
+			}
+
+			pageToken = commentList.NextPageToken
+			if pageToken == "" {
+				return
+			}
+		}
+	}()
+
+	return resultChan
+}
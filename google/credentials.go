@@ -0,0 +1,241 @@
+package google
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+	"golang.org/x/oauth2/jwt"
+)
+
+// x509CertsURL serves the public certificates of the keys of a service account
+const x509CertsURL = "https://www.googleapis.com/robot/v1/metadata/x509/"
+
+// credential is a candidate set of credentials to authenticate with
+type credential struct {
+	// name identifies the credential in logs without exposing it
+	name        string
+	jwt         *jwt.Config
+	tokenSource oauth2.TokenSource
+}
+
+// credentials returns the credentials configured in c, in the order they are tried:
+// the inline private key, the key files, then application default credentials. ADC are
+// used when no key is configured. Keys that cannot be read or parsed are skipped with a
+// warning, so the remaining ones are still tried. Service-account keys impersonate
+// c.Subject.
+func (c Config) credentials(ctx context.Context) ([]credential, error) {
+	var (
+		creds   []credential
+		invalid []error
+	)
+	addKey := func(name string, jwtConfig *jwt.Config, err error) {
+		if err == nil {
+			err = validatePrivateKey(jwtConfig.PrivateKey)
+		}
+		if err != nil {
+			c.logger().Warn("skipping invalid google credential", "credential", name, "error", err.Error())
+			invalid = append(invalid, fmt.Errorf("%s: %w", name, err))
+			return
+		}
+		creds = append(creds, credential{name: name, jwt: jwtConfig})
+	}
+
+	if c.PrivateKey != "" {
+		addKey("private_key", &jwt.Config{
+			Email:        c.ClientEmail,
+			PrivateKey:   []byte(c.PrivateKey),
+			PrivateKeyID: c.PrivateKeyID,
+			Scopes:       c.Scopes,
+			TokenURL:     google.JWTTokenURL,
+		}, nil)
+	}
+
+	for _, path := range c.KeyFiles {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			addKey(path, nil, fmt.Errorf("failed to read key file: %w", err))
+			continue
+		}
+		jwtConfig, err := google.JWTConfigFromJSON(data, c.Scopes...)
+		if err != nil {
+			err = fmt.Errorf("failed to parse key file: %w", err)
+		}
+		addKey(path, jwtConfig, err)
+	}
+
+	keysConfigured := c.PrivateKey != "" || len(c.KeyFiles) > 0
+	if c.DefaultCredentials || !keysConfigured {
+		defaultCreds, err := google.FindDefaultCredentials(ctx, c.Scopes...)
+		if err != nil {
+			if len(creds) > 0 {
+				// ADC are only a last resort when keys are configured
+				c.logger().Warn("application default credentials not found", "error", err.Error())
+				return creds, nil
+			}
+			return nil, fmt.Errorf("failed to find application default credentials: %w", err)
+		}
+
+		cred := credential{name: "application_default", tokenSource: defaultCreds.TokenSource}
+		// service-account keys found through GOOGLE_APPLICATION_CREDENTIALS are handled
+		// like key files, so their age can be reported
+		if jwtConfig, err := google.JWTConfigFromJSON(defaultCreds.JSON, c.Scopes...); err == nil {
+			cred.jwt = jwtConfig
+		}
+		creds = append(creds, cred)
+	}
+
+	if len(creds) == 0 {
+		return nil, fmt.Errorf("no valid google credentials: %w", errors.Join(invalid...))
+	}
+
+	for i := range creds {
+		if creds[i].jwt == nil {
+			if c.Subject != "" {
+				return nil, fmt.Errorf("credential %s cannot impersonate %s: domain-wide delegation requires a service-account key",
+					creds[i].name, c.Subject)
+			}
+			continue
+		}
+		creds[i].jwt.Subject = c.Subject
+		creds[i].tokenSource = creds[i].jwt.TokenSource(ctx)
+	}
+	return creds, nil
+}
+
+// validatePrivateKey checks that a service-account key is an RSA key, PEM encoded or
+// not, the way the JWT token source reads it when signing its first token request
+func validatePrivateKey(key []byte) error {
+	if block, _ := pem.Decode(key); block != nil {
+		key = block.Bytes
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(key)
+	if err != nil {
+		if _, err := x509.ParsePKCS1PrivateKey(key); err != nil {
+			return fmt.Errorf("failed to parse private key: %w", err)
+		}
+		return nil
+	}
+	if _, ok := parsed.(*rsa.PrivateKey); !ok {
+		return errors.New("private key is not an RSA key")
+	}
+	return nil
+}
+
+// logKeyAge reports the age and expiry of the service-account keys, read from the
+// public certificates Google publishes for every key. Failing to get a certificate is
+// only logged, as it does not prevent authenticating.
+func logKeyAge(ctx context.Context, logger *slog.Logger, creds []credential) {
+	now := time.Now()
+	for _, cred := range creds {
+		logger := logger.With("credential", cred.name)
+		if cred.jwt == nil {
+			logger.Info("using application default credentials")
+			continue
+		}
+		logger = logger.With("client_email", cred.jwt.Email, "key_id", cred.jwt.PrivateKeyID)
+
+		cert, err := fetchKeyCertificate(ctx, cred.jwt.Email, cred.jwt.PrivateKeyID)
+		if err != nil {
+			logger.Warn("failed to get service account key certificate", "error", err.Error())
+			continue
+		}
+
+		attrs := []any{
+			"created_at", cert.NotBefore,
+			"age_days", int(now.Sub(cert.NotBefore).Hours() / 24),
+		}
+		// keys without an expiry have certificates valid until the year 9999
+		if cert.NotAfter.Year() < 9999 {
+			attrs = append(attrs, "expires_at", cert.NotAfter)
+			if cert.NotAfter.Sub(now) < 30*24*time.Hour {
+				logger.Warn("service account key expires soon", attrs...)
+				continue
+			}
+		}
+		logger.Info("service account key loaded", attrs...)
+	}
+}
+
+// fetchKeyCertificate returns the public certificate of a service-account key
+func fetchKeyCertificate(ctx context.Context, email, keyID string) (*x509.Certificate, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, x509CertsURL+url.PathEscape(email), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	var certs map[string]string
+	if err := json.NewDecoder(resp.Body).Decode(&certs); err != nil {
+		return nil, fmt.Errorf("failed to decode certificates: %w", err)
+	}
+	block, _ := pem.Decode([]byte(certs[keyID]))
+	if block == nil {
+		return nil, fmt.Errorf("no certificate published for key %s", keyID)
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// fallbackTokenSource gets tokens from the first credential that works, moving on to
+// the next one when getting a token fails, e.g. because a rotated key was deleted. The
+// credential that worked last is tried first.
+type fallbackTokenSource struct {
+	logger  *slog.Logger
+	creds   []credential
+	mu      sync.Mutex
+	current int
+}
+
+func (f *fallbackTokenSource) Token() (*oauth2.Token, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var errs []error
+	for i := range f.creds {
+		n := (f.current + i) % len(f.creds)
+		cred := f.creds[n]
+
+		token, err := cred.tokenSource.Token()
+		if err == nil {
+			if n != f.current {
+				f.logger.Warn("switched google credentials", "credential", cred.name)
+				f.current = n
+			}
+			return token, nil
+		}
+
+		f.logger.Warn("failed to get a token with google credentials", "credential", cred.name, "error", err.Error())
+		errs = append(errs, fmt.Errorf("%s: %w", cred.name, err))
+	}
+	return nil, fmt.Errorf("all google credentials failed: %w", errors.Join(errs...))
+}
+
+func (c Config) logger() *slog.Logger {
+	logger := c.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return logger.With("component", "google_credentials")
+}
@@ -0,0 +1,100 @@
+package fake
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/canonical/specs-v2.canonical.com/google"
+	"google.golang.org/api/driveactivity/v2"
+)
+
+// CurrentPersonName is the Drive Activity person name of the fake service account,
+// the actor of the edits made through the fake
+const CurrentPersonName = "people/fake"
+
+// activityTimeFilter matches the time conditions of an activity query filter
+var activityTimeFilter = regexp.MustCompile(`time\s*(<=|>=|<|>)\s*"([^"]+)"`)
+
+// recordEdit adds an edit by the fake service account to the activity of a file. The
+// caller must hold the lock.
+func (h *Handler) recordEdit(f *fixture) {
+	f.Activities = append(f.Activities, &driveactivity.DriveActivity{
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Actors: []*driveactivity.Actor{{
+			User: &driveactivity.User{KnownUser: &driveactivity.KnownUser{
+				PersonName:    CurrentPersonName,
+				IsCurrentUser: true,
+			}},
+		}},
+		PrimaryActionDetail: &driveactivity.ActionDetail{Edit: &driveactivity.Edit{}},
+	})
+}
+
+// queryActivity serves the activity of a fixture, read from its "<name>.activity.json"
+// sidecar and extended with the edits made through the fake. Only the item name, the
+// time conditions and an EDIT action filter are supported, and everything fits on a
+// single page.
+func (h *Handler) queryActivity(w http.ResponseWriter, r *http.Request) {
+	var req driveactivity.QueryDriveActivityRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	fileID, ok := strings.CutPrefix(req.ItemName, "items/")
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Only item names are supported by fake: "+req.ItemName)
+		return
+	}
+	f, ok := h.fixture(fileID)
+	if !ok {
+		writeError(w, http.StatusNotFound, "Requested entity was not found.")
+		return
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	editsOnly := strings.Contains(req.Filter, "detail.action_detail_case:EDIT")
+	resp := &driveactivity.QueryDriveActivityResponse{}
+	// newest first, like the real API
+	for i := len(f.Activities) - 1; i >= 0; i-- {
+		activity := f.Activities[i]
+		if editsOnly && (activity.PrimaryActionDetail == nil || activity.PrimaryActionDetail.Edit == nil) {
+			continue
+		}
+		at, err := google.ActivityTime(activity)
+		if err != nil || !matchesActivityTime(req.Filter, at) {
+			continue
+		}
+		resp.Activities = append(resp.Activities, activity)
+	}
+	writeJSON(w, resp)
+}
+
+// matchesActivityTime reports whether a time satisfies the time conditions of a filter
+func matchesActivityTime(filter string, at time.Time) bool {
+	for _, match := range activityTimeFilter.FindAllStringSubmatch(filter, -1) {
+		bound, err := time.Parse(time.RFC3339, match[2])
+		if err != nil {
+			return false
+		}
+		var ok bool
+		switch match[1] {
+		case "<":
+			ok = at.Before(bound)
+		case "<=":
+			ok = !at.After(bound)
+		case ">":
+			ok = at.After(bound)
+		case ">=":
+			ok = !at.Before(bound)
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,220 @@
+package fake
+
+import (
+	"net/http"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+	"google.golang.org/api/docs/v1"
+)
+
+// documentBuilder converts an HTML export into the Docs API structure, keeping track of
+// the UTF-16 based indices the Docs API uses to address content.
+type documentBuilder struct {
+	index int64
+}
+
+// documentFromHTML builds a Docs API document from the paragraphs, headings and tables
+// of an HTML export. Links are kept on their text runs, and mailto links become person
+// chips as the export renders chips that way.
+//
+// Top level <section data-tab-id="..." data-tab-title="..."> elements stand for the tabs
+// of the Doc, which the HTML export flattens into a single body. Each one becomes a tab
+// with its own indices, and the body is the content of the first tab like the Docs API
+// returns it without includeTabsContent.
+func documentFromHTML(docID, title, content string) (*docs.Document, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+
+	document := &docs.Document{
+		DocumentId: docID,
+		Title:      title,
+	}
+
+	sections := doc.Find("body").ChildrenFiltered("section[data-tab-id]")
+	if sections.Length() == 0 {
+		document.Body = bodyFromHTML(doc.Find("body"))
+		return document, nil
+	}
+
+	sections.Each(func(i int, section *goquery.Selection) {
+		document.Tabs = append(document.Tabs, &docs.Tab{
+			TabProperties: &docs.TabProperties{
+				TabId: section.AttrOr("data-tab-id", ""),
+				Title: section.AttrOr("data-tab-title", ""),
+				Index: int64(i),
+			},
+			DocumentTab: &docs.DocumentTab{Body: bodyFromHTML(section)},
+		})
+	})
+	document.Body = document.Tabs[0].DocumentTab.Body
+	return document, nil
+}
+
+// bodyFromHTML converts the children of an element into the body of a document or tab
+func bodyFromHTML(parent *goquery.Selection) *docs.Body {
+	b := &documentBuilder{index: 1}
+	body := &docs.Body{
+		Content: []*docs.StructuralElement{{
+			EndIndex:     1,
+			SectionBreak: &docs.SectionBreak{},
+		}},
+	}
+
+	parent.Children().Each(func(_ int, s *goquery.Selection) {
+		switch goquery.NodeName(s) {
+		case "table":
+			body.Content = append(body.Content, b.table(s))
+		case "h1", "h2", "h3", "h4", "h5", "h6":
+			elem := b.paragraph(s)
+			elem.Paragraph.ParagraphStyle = &docs.ParagraphStyle{
+				NamedStyleType: "HEADING_" + strings.TrimPrefix(goquery.NodeName(s), "h"),
+			}
+			body.Content = append(body.Content, elem)
+		default:
+			body.Content = append(body.Content, b.paragraph(s))
+		}
+	})
+	return body
+}
+
+// withTabs returns a copy of a document shaped by the includeTabsContent parameter: the
+// content of every tab in tabs and no body when set, the first tab in the body and no
+// tabs otherwise. A Doc without tabs has a single one when they are included.
+func withTabs(r *http.Request, document *docs.Document) *docs.Document {
+	shaped := *document
+	if r.URL.Query().Get("includeTabsContent") != "true" {
+		shaped.Tabs = nil
+		return &shaped
+	}
+
+	if len(shaped.Tabs) == 0 {
+		shaped.Tabs = []*docs.Tab{{
+			TabProperties: &docs.TabProperties{TabId: "t.0"},
+			DocumentTab:   &docs.DocumentTab{Body: document.Body},
+		}}
+	}
+	shaped.Body = nil
+	return &shaped
+}
+
+// paragraph converts a block element into a paragraph ending with a newline
+func (b *documentBuilder) paragraph(s *goquery.Selection) *docs.StructuralElement {
+	start := b.index
+	paragraph := &docs.Paragraph{}
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		switch {
+		case n.Type == html.TextNode:
+			paragraph.Elements = append(paragraph.Elements, b.textRun(n.Data, ""))
+		case n.Type == html.ElementNode && n.Data == "a":
+			href := ""
+			for _, attr := range n.Attr {
+				if attr.Key == "href" {
+					href = attr.Val
+				}
+			}
+			text := goquery.NewDocumentFromNode(n).Text()
+			if email, ok := strings.CutPrefix(href, "mailto:"); ok {
+				paragraph.Elements = append(paragraph.Elements, b.person(text, email))
+			} else {
+				paragraph.Elements = append(paragraph.Elements, b.textRun(text, href))
+			}
+		default:
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				walk(c)
+			}
+		}
+	}
+	for _, n := range s.Nodes {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	paragraph.Elements = append(paragraph.Elements, b.textRun("\n", ""))
+
+	return &docs.StructuralElement{
+		StartIndex: start,
+		EndIndex:   b.index,
+		Paragraph:  paragraph,
+	}
+}
+
+// table converts a table element. Each row and each cell take up one index before
+// their content, matching the layout returned by the Docs API.
+func (b *documentBuilder) table(s *goquery.Selection) *docs.StructuralElement {
+	start := b.index
+	b.index++
+
+	table := &docs.Table{}
+	s.Find("tr").Each(func(_ int, tr *goquery.Selection) {
+		row := &docs.TableRow{StartIndex: b.index}
+		b.index++
+
+		tr.Find("th, td").Each(func(_ int, td *goquery.Selection) {
+			cell := &docs.TableCell{StartIndex: b.index}
+			b.index++
+
+			paragraphs := td.ChildrenFiltered("p")
+			if paragraphs.Length() == 0 {
+				paragraphs = td
+			}
+			paragraphs.Each(func(_ int, p *goquery.Selection) {
+				cell.Content = append(cell.Content, b.paragraph(p))
+			})
+
+			cell.EndIndex = b.index
+			row.TableCells = append(row.TableCells, cell)
+		})
+
+		row.EndIndex = b.index
+		table.TableRows = append(table.TableRows, row)
+		if len(row.TableCells) > int(table.Columns) {
+			table.Columns = int64(len(row.TableCells))
+		}
+	})
+	table.Rows = int64(len(table.TableRows))
+
+	return &docs.StructuralElement{
+		StartIndex: start,
+		EndIndex:   b.index,
+		Table:      table,
+	}
+}
+
+// textRun creates a text run at the current index, optionally linked
+func (b *documentBuilder) textRun(text, link string) *docs.ParagraphElement {
+	start := b.index
+	b.index += int64(len(utf16.Encode([]rune(text))))
+
+	run := &docs.TextRun{Content: text}
+	if link != "" {
+		run.TextStyle = &docs.TextStyle{Link: &docs.Link{Url: link}}
+	}
+
+	return &docs.ParagraphElement{
+		StartIndex: start,
+		EndIndex:   b.index,
+		TextRun:    run,
+	}
+}
+
+// person creates a person chip at the current index, chips take up a single index
+func (b *documentBuilder) person(name, email string) *docs.ParagraphElement {
+	start := b.index
+	b.index++
+
+	return &docs.ParagraphElement{
+		StartIndex: start,
+		EndIndex:   b.index,
+		Person: &docs.Person{
+			PersonId:         "person-" + email,
+			PersonProperties: &docs.PersonProperties{Name: name, Email: email},
+		},
+	}
+}
@@ -0,0 +1,311 @@
+package fake
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/canonical/specs-v2.canonical.com/google"
+	"google.golang.org/api/docs/v1"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/driveactivity/v2"
+)
+
+// RootFolderID is the Drive ID of the fixtures root directory
+const RootFolderID = "root"
+
+const (
+	extHTML      = ".html"
+	extDocs      = ".json"
+	extMeta      = ".meta.json"
+	extRevisions = ".revisions.json"
+	extComments  = ".comments.json"
+	extActivity  = ".activity.json"
+)
+
+// fixture holds everything the fake knows about a single Drive file
+type fixture struct {
+	File *drive.File
+	// HTML is the content returned when exporting the file as text/html
+	HTML string
+	// Document is the Docs API representation of the file
+	Document *docs.Document
+	// Content is the content of an uploaded file, or the CSV export of a Google Sheet
+	Content []byte
+	// Revisions are the past revisions of a Google Doc, oldest first. The current
+	// content is served as an extra revision after them.
+	Revisions []*revision
+	// Comments are the comments on the file, with their replies
+	Comments []*drive.Comment
+	// Activities are the Drive Activity records of the file, oldest first
+	Activities []*driveactivity.DriveActivity
+}
+
+// revision is a past revision of a Google Doc
+type revision struct {
+	drive.Revision
+	// HTML is the revision exported as text/html, revisions without it cannot be exported
+	HTML string `json:"html"`
+}
+
+// uploadedMimeTypes maps the extensions of fixtures that are not Google Docs to the MIME
+// type of the Drive file they stand for
+var uploadedMimeTypes = map[string]string{
+	".csv":  google.MimeTypeSheet,
+	".md":   google.MimeTypeMarkdown,
+	".pdf":  google.MimeTypePDF,
+	".docx": google.MimeTypeDocx,
+}
+
+// loadFixtures walks dir and builds the Drive tree it describes.
+//
+// The fixtures directory is laid out the same way as the Drive tree it stands in for:
+//   - every directory is a folder, the fixtures directory itself being RootFolderID
+//   - every "<name>.html" file is a Google Doc called <name>, served as its HTML export
+//   - an optional "<name>.json" file holds the Docs API JSON for that Doc; when missing it
+//     is generated from the HTML
+//   - an optional "<name>.meta.json" file holds Drive file fields (id, modifiedTime,
+//     mimeType, ...) that override the generated ones
+//   - an optional "<name>.revisions.json" file lists past revisions of the Doc, each with
+//     its Drive revision fields and its HTML export in "html"
+//   - an optional "<name>.comments.json" file lists the Drive comments on the Doc
+//   - an optional "<name>.activity.json" file lists the Drive Activity records of the
+//     Doc, oldest first
+//   - "<name>.md", "<name>.pdf" and "<name>.docx" files are uploaded files served as they
+//     are, with their fields in "<name>.<ext>.meta.json" and their comments in
+//     "<name>.<ext>.comments.json", and "<name>.csv" files are Google Sheets exported as
+//     that CSV
+//
+// A directory may also have a "<dir>.meta.json" sibling overriding its folder fields. A
+// "<name>.meta.json" file without a matching Doc or directory describes a file on its
+// own, such as a shortcut with its shortcutDetails.
+//
+// The labels applied to a file are set in the "labelInfo" of its meta file, and the
+// label definitions are read from a "labels.json" file at the root by loadLabels.
+//
+// A directory whose meta file sets "driveId" to its own ID stands for the root of a
+// shared drive, and everything below it belongs to that drive. Setting "parents" to an
+// empty list keeps it out of the root folder, like a real shared drive.
+func loadFixtures(dir string) (map[string]*fixture, error) {
+	fixtures := map[string]*fixture{}
+	pathIDs := map[string]string{".": RootFolderID}
+
+	fixtures[RootFolderID] = &fixture{File: &drive.File{
+		Id:       RootFolderID,
+		Name:     filepath.Base(dir),
+		MimeType: google.MimeTypeFolder,
+	}}
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil || rel == "." {
+			return err
+		}
+
+		parentID, ok := pathIDs[filepath.Dir(rel)]
+		if !ok {
+			return fmt.Errorf("parent of %s was not loaded", rel)
+		}
+		// files inherit the shared drive of their parent folder
+		driveID := fixtures[parentID].File.DriveId
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		modifiedTime := info.ModTime().UTC().Format(time.RFC3339)
+
+		if d.IsDir() {
+			f := &fixture{File: &drive.File{
+				Id:           fixtureID(rel),
+				Name:         d.Name(),
+				MimeType:     google.MimeTypeFolder,
+				Parents:      []string{parentID},
+				DriveId:      driveID,
+				CreatedTime:  modifiedTime,
+				ModifiedTime: modifiedTime,
+			}}
+			if err := applyMeta(path+extMeta, f.File); err != nil {
+				return err
+			}
+			pathIDs[rel] = f.File.Id
+			fixtures[f.File.Id] = f
+			return nil
+		}
+
+		if strings.HasSuffix(d.Name(), extMeta) {
+			return loadMetaOnly(fixtures, path, rel, parentID, driveID)
+		}
+		if mimeType, ok := uploadedMimeTypes[filepath.Ext(d.Name())]; ok {
+			return loadUploaded(fixtures, path, rel, parentID, driveID, mimeType, modifiedTime)
+		}
+		if !strings.HasSuffix(d.Name(), extHTML) {
+			return nil
+		}
+
+		name := strings.TrimSuffix(d.Name(), extHTML)
+		base := strings.TrimSuffix(path, extHTML)
+		html, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		f := &fixture{
+			File: &drive.File{
+				Id:           fixtureID(strings.TrimSuffix(rel, extHTML)),
+				Name:         name,
+				MimeType:     google.MimeTypeDocument,
+				Parents:      []string{parentID},
+				DriveId:      driveID,
+				CreatedTime:  modifiedTime,
+				ModifiedTime: modifiedTime,
+			},
+			HTML: string(html),
+		}
+		if err := applyMeta(base+extMeta, f.File); err != nil {
+			return err
+		}
+		f.File.WebViewLink = fmt.Sprintf("https://docs.google.com/document/d/%s/edit", f.File.Id)
+
+		if content, err := os.ReadFile(base + extDocs); err == nil {
+			f.Document = &docs.Document{}
+			if err := json.Unmarshal(content, f.Document); err != nil {
+				return fmt.Errorf("failed to decode %s: %w", base+extDocs, err)
+			}
+		} else if os.IsNotExist(err) {
+			f.Document, err = documentFromHTML(f.File.Id, f.File.Name, f.HTML)
+			if err != nil {
+				return fmt.Errorf("failed to generate document for %s: %w", rel, err)
+			}
+		} else {
+			return err
+		}
+
+		if err := loadSidecar(base+extRevisions, &f.Revisions); err != nil {
+			return err
+		}
+		if err := loadSidecar(base+extComments, &f.Comments); err != nil {
+			return err
+		}
+		if err := loadSidecar(base+extActivity, &f.Activities); err != nil {
+			return err
+		}
+
+		fixtures[f.File.Id] = f
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return fixtures, nil
+}
+
+// loadUploaded loads a file that is not a Google Doc. Uploaded files keep their extension
+// in their name like on Drive, while a CSV file stands for a Google Sheet.
+func loadUploaded(fixtures map[string]*fixture, path, rel, parentID, driveID, mimeType, modifiedTime string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	name := filepath.Base(path)
+	if mimeType == google.MimeTypeSheet {
+		name = strings.TrimSuffix(name, filepath.Ext(name))
+	}
+
+	f := &fixture{
+		File: &drive.File{
+			Id:           fixtureID(rel),
+			Name:         name,
+			MimeType:     mimeType,
+			Parents:      []string{parentID},
+			DriveId:      driveID,
+			CreatedTime:  modifiedTime,
+			ModifiedTime: modifiedTime,
+			WebViewLink:  fmt.Sprintf("https://drive.google.com/file/d/%s/view", fixtureID(rel)),
+		},
+		Content: content,
+	}
+	if err := applyMeta(path+extMeta, f.File); err != nil {
+		return err
+	}
+	if err := loadSidecar(path+extComments, &f.Comments); err != nil {
+		return err
+	}
+
+	fixtures[f.File.Id] = f
+	return nil
+}
+
+// loadMetaOnly loads a file described only by its meta file
+func loadMetaOnly(fixtures map[string]*fixture, path, rel, parentID, driveID string) error {
+	base := strings.TrimSuffix(path, extMeta)
+	if _, err := os.Stat(base + extHTML); err == nil {
+		return nil
+	}
+	if _, err := os.Stat(base); err == nil {
+		return nil
+	}
+
+	f := &fixture{File: &drive.File{
+		Id:      fixtureID(strings.TrimSuffix(rel, extMeta)),
+		Name:    filepath.Base(base),
+		Parents: []string{parentID},
+		DriveId: driveID,
+	}}
+	if err := applyMeta(path, f.File); err != nil {
+		return err
+	}
+	if f.File.MimeType == "" {
+		return fmt.Errorf("%s must set a mimeType", path)
+	}
+
+	fixtures[f.File.Id] = f
+	return nil
+}
+
+// applyMeta overrides file fields with the content of a meta file, if present
+func applyMeta(path string, file *drive.File) error {
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(content, file); err != nil {
+		return fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	return nil
+}
+
+// loadSidecar decodes an optional JSON file describing more of a fixture into v
+func loadSidecar(path string, v any) error {
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(content, v); err != nil {
+		return fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	return nil
+}
+
+// fixtureID derives a stable Drive-like ID from a fixture path
+func fixtureID(rel string) string {
+	sum := sha1.Sum([]byte(filepath.ToSlash(rel)))
+	return "fake-" + hex.EncodeToString(sum[:])[:16]
+}
@@ -0,0 +1,62 @@
+package fake
+
+import (
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/drivelabels/v2"
+)
+
+// labelsFile lists the Drive label definitions in the fixtures directory, with the
+// labels applied to a file set in the "labelInfo" of its meta file
+const labelsFile = "labels.json"
+
+// loadLabels reads the optional label definitions of a fixtures directory
+func loadLabels(dir string) (map[string]*drivelabels.GoogleAppsDriveLabelsV2Label, error) {
+	var list []*drivelabels.GoogleAppsDriveLabelsV2Label
+	if err := loadSidecar(filepath.Join(dir, labelsFile), &list); err != nil {
+		return nil, err
+	}
+
+	labels := map[string]*drivelabels.GoogleAppsDriveLabelsV2Label{}
+	for _, label := range list {
+		labels[label.Id] = label
+	}
+	return labels, nil
+}
+
+// withLabels returns a copy of a file only carrying the labels requested with the
+// includeLabels parameter, as Drive returns none by default
+func withLabels(r *http.Request, file *drive.File) *drive.File {
+	if file.LabelInfo == nil {
+		return file
+	}
+
+	requested := strings.Split(r.URL.Query().Get("includeLabels"), ",")
+	info := &drive.FileLabelInfo{}
+	for _, label := range file.LabelInfo.Labels {
+		if slices.Contains(requested, label.Id) {
+			info.Labels = append(info.Labels, label)
+		}
+	}
+
+	withInfo := *file
+	withInfo.LabelInfo = nil
+	if len(info.Labels) > 0 {
+		withInfo.LabelInfo = info
+	}
+	return &withInfo
+}
+
+// getLabel serves a label definition, all views including the fields and their choices
+func (h *Handler) getLabel(w http.ResponseWriter, r *http.Request) {
+	label, ok := h.labels[r.PathValue("labelId")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Label not found: "+r.PathValue("labelId"))
+		return
+	}
+	writeJSON(w, label)
+}
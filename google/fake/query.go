@@ -0,0 +1,312 @@
+package fake
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+
+	"google.golang.org/api/drive/v3"
+)
+
+// predicate reports whether a file matches a parsed Drive query
+type predicate func(f *drive.File) bool
+
+// parseQuery parses the subset of the Drive query language used by this service:
+// comparisons on name, mimeType, trashed, starred, sharedWithMe, createdTime and
+// modifiedTime, "contains" on name and fullText, "'<value>' in" parents, owners, writers
+// and readers, "has { key='<key>' and value='<value>' }" on properties and
+// appProperties, "not", "and", "or" and parentheses.
+func parseQuery(query string) (predicate, error) {
+	tokens, err := tokenize(query)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return func(*drive.File) bool { return true }, nil
+	}
+
+	p := &queryParser{tokens: tokens}
+	pred, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos != len(p.tokens) {
+		return nil, fmt.Errorf("unexpected token %q", p.tokens[p.pos].value)
+	}
+	return pred, nil
+}
+
+type token struct {
+	value  string
+	quoted bool
+}
+
+func tokenize(query string) ([]token, error) {
+	var tokens []token
+	runes := []rune(query)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case strings.ContainsRune("(){}", r):
+			tokens = append(tokens, token{value: string(r)})
+			i++
+		case r == '\'':
+			var sb strings.Builder
+			i++
+			for ; i < len(runes) && runes[i] != '\''; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				sb.WriteRune(runes[i])
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("unterminated string in query")
+			}
+			tokens = append(tokens, token{value: sb.String(), quoted: true})
+			i++
+		case strings.ContainsRune("=!<>", r):
+			j := i + 1
+			if j < len(runes) && runes[j] == '=' {
+				j++
+			}
+			tokens = append(tokens, token{value: string(runes[i:j])})
+			i = j
+		default:
+			j := i
+			for j < len(runes) && !unicode.IsSpace(runes[j]) && !strings.ContainsRune("(){}=!<>'", runes[j]) {
+				j++
+			}
+			tokens = append(tokens, token{value: string(runes[i:j])})
+			i = j
+		}
+	}
+
+	return tokens, nil
+}
+
+type queryParser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *queryParser) peekKeyword(keyword string) bool {
+	return p.pos < len(p.tokens) && !p.tokens[p.pos].quoted && strings.EqualFold(p.tokens[p.pos].value, keyword)
+}
+
+func (p *queryParser) next() (token, error) {
+	if p.pos >= len(p.tokens) {
+		return token{}, fmt.Errorf("unexpected end of query")
+	}
+	t := p.tokens[p.pos]
+	p.pos++
+	return t, nil
+}
+
+func (p *queryParser) or() (predicate, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekKeyword("or") {
+		p.pos++
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(f *drive.File) bool { return l(f) || right(f) }
+	}
+	return left, nil
+}
+
+func (p *queryParser) and() (predicate, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekKeyword("and") {
+		p.pos++
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(f *drive.File) bool { return l(f) && right(f) }
+	}
+	return left, nil
+}
+
+func (p *queryParser) unary() (predicate, error) {
+	if p.peekKeyword("not") {
+		p.pos++
+		inner, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return func(f *drive.File) bool { return !inner(f) }, nil
+	}
+
+	if p.peekKeyword("(") {
+		p.pos++
+		inner, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if !p.peekKeyword(")") {
+			return nil, fmt.Errorf("missing closing parenthesis")
+		}
+		p.pos++
+		return inner, nil
+	}
+
+	return p.term()
+}
+
+func (p *queryParser) term() (predicate, error) {
+	left, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	op, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	right, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+
+	if left.quoted && strings.EqualFold(op.value, "in") {
+		collection, err := fileCollection(right.value)
+		if err != nil {
+			return nil, err
+		}
+		return func(f *drive.File) bool {
+			return slices.ContainsFunc(collection(f), func(v string) bool { return strings.EqualFold(v, left.value) })
+		}, nil
+	}
+
+	if strings.EqualFold(op.value, "has") {
+		p.pos--
+		return p.hasProperty(left.value)
+	}
+
+	field := func(f *drive.File) string { return "" }
+	switch left.value {
+	case "name":
+		field = func(f *drive.File) string { return f.Name }
+	case "mimeType":
+		field = func(f *drive.File) string { return f.MimeType }
+	case "createdTime":
+		field = func(f *drive.File) string { return f.CreatedTime }
+	case "modifiedTime":
+		field = func(f *drive.File) string { return f.ModifiedTime }
+	case "trashed":
+		field = func(f *drive.File) string { return fmt.Sprint(f.Trashed) }
+	case "starred":
+		field = func(f *drive.File) string { return fmt.Sprint(f.Starred) }
+	case "sharedWithMe":
+		field = func(f *drive.File) string { return fmt.Sprint(f.SharedWithMeTime != "") }
+	case "fullText":
+		field = func(f *drive.File) string { return f.Name + "\n" + f.Description }
+	default:
+		return nil, fmt.Errorf("unsupported query field %q", left.value)
+	}
+
+	value := right.value
+	switch op.value {
+	case "=":
+		return func(f *drive.File) bool { return field(f) == value }, nil
+	case "!=":
+		return func(f *drive.File) bool { return field(f) != value }, nil
+	case "<":
+		return func(f *drive.File) bool { return field(f) < value }, nil
+	case "<=":
+		return func(f *drive.File) bool { return field(f) <= value }, nil
+	case ">":
+		return func(f *drive.File) bool { return field(f) > value }, nil
+	case ">=":
+		return func(f *drive.File) bool { return field(f) >= value }, nil
+	case "contains":
+		return func(f *drive.File) bool {
+			return strings.Contains(strings.ToLower(field(f)), strings.ToLower(value))
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported query operator %q", op.value)
+}
+
+// hasProperty parses "{ key='<key>' and value='<value>' }" following a "has" operator
+func (p *queryParser) hasProperty(field string) (predicate, error) {
+	var properties func(f *drive.File) map[string]string
+	switch field {
+	case "properties":
+		properties = func(f *drive.File) map[string]string { return f.Properties }
+	case "appProperties":
+		properties = func(f *drive.File) map[string]string { return f.AppProperties }
+	default:
+		return nil, fmt.Errorf("unsupported properties field %q", field)
+	}
+
+	var parts []string
+	for _, expected := range []string{"{", "key", "=", "", "and", "value", "=", "", "}"} {
+		t, err := p.next()
+		if err != nil {
+			return nil, err
+		}
+		if expected == "" {
+			if !t.quoted {
+				return nil, fmt.Errorf("expected a string, got %q", t.value)
+			}
+			parts = append(parts, t.value)
+			continue
+		}
+		if t.quoted || t.value != expected {
+			return nil, fmt.Errorf("expected %q, got %q", expected, t.value)
+		}
+	}
+
+	key, value := parts[0], parts[1]
+	return func(f *drive.File) bool {
+		v, ok := properties(f)[key]
+		return ok && v == value
+	}, nil
+}
+
+// fileCollection returns the values of a collection that can be searched with "in"
+func fileCollection(name string) (func(f *drive.File) []string, error) {
+	switch name {
+	case "parents":
+		return func(f *drive.File) []string { return f.Parents }, nil
+	case "owners":
+		return func(f *drive.File) []string {
+			var emails []string
+			for _, owner := range f.Owners {
+				emails = append(emails, owner.EmailAddress)
+			}
+			return emails
+		}, nil
+	case "writers":
+		return permissionEmails("owner", "organizer", "fileOrganizer", "writer"), nil
+	case "readers":
+		return permissionEmails("owner", "organizer", "fileOrganizer", "writer", "commenter", "reader"), nil
+	}
+	return nil, fmt.Errorf("unsupported collection %q", name)
+}
+
+// permissionEmails returns the users and groups granted one of the given roles
+func permissionEmails(roles ...string) func(f *drive.File) []string {
+	return func(f *drive.File) []string {
+		var emails []string
+		for _, permission := range f.Permissions {
+			if permission.EmailAddress != "" && slices.Contains(roles, permission.Role) {
+				emails = append(emails, permission.EmailAddress)
+			}
+		}
+		return emails
+	}
+}
@@ -0,0 +1,704 @@
+// Package fake provides an in-process stand-in for the Drive and Docs APIs, serving a
+// spec tree from fixtures on disk. Point a google.Google client at it with
+// google.NewGoogleWithEndpoint, or set the GoogleAPIEndpoint config to the address of
+// cmd/fakegoogle, to run the sync and reject pipelines without Google credentials.
+package fake
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/canonical/specs-v2.canonical.com/google"
+	"google.golang.org/api/docs/v1"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/drivelabels/v2"
+)
+
+// defaultPageSize mirrors the Drive API default for files.list
+const defaultPageSize = 100
+
+// Handler serves the fake Drive and Docs APIs
+type Handler struct {
+	Logger *slog.Logger
+
+	mu       sync.RWMutex
+	fixtures map[string]*fixture
+	// labels are the Drive label definitions, by label ID
+	labels  map[string]*drivelabels.GoogleAppsDriveLabelsV2Label
+	updates map[string][]*docs.Request
+	// changes is the Drive changes feed, page tokens are offsets into it
+	changes []*drive.Change
+	// watches are the open push notification channels, by channel ID
+	watches map[string]*watch
+	mux     *http.ServeMux
+}
+
+// watch is a push notification channel on a file, or on the changes feed when FileID is empty
+type watch struct {
+	Channel drive.Channel
+	FileID  string
+}
+
+// Server is a Handler listening on a local httptest server
+type Server struct {
+	*httptest.Server
+	*Handler
+}
+
+// NewHandler loads the fixtures in dir and returns a handler serving them
+func NewHandler(logger *slog.Logger, dir string) (*Handler, error) {
+	fixtures, err := loadFixtures(dir)
+	if err != nil {
+		return nil, err
+	}
+	labels, err := loadLabels(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range fixtures {
+		if f.File.Version == 0 {
+			f.File.Version = 1
+		}
+	}
+
+	h := &Handler{
+		Logger:   logger.With("component", "fake_google"),
+		fixtures: fixtures,
+		labels:   labels,
+		updates:  map[string][]*docs.Request{},
+		watches:  map[string]*watch{},
+		mux:      http.NewServeMux(),
+	}
+
+	h.mux.HandleFunc("GET /drive/v3/about", h.about)
+	h.mux.HandleFunc("GET /drive/v3/files", h.listFiles)
+	h.mux.HandleFunc("GET /drive/v3/files/{fileId}", h.getFile)
+	h.mux.HandleFunc("PATCH /drive/v3/files/{fileId}", h.updateFile)
+	h.mux.HandleFunc("GET /drive/v3/files/{fileId}/export", h.exportFile)
+	h.mux.HandleFunc("GET /drive/v3/files/{fileId}/revisions", h.listRevisions)
+	h.mux.HandleFunc("GET /drive/v3/files/{fileId}/comments", h.listComments)
+	h.mux.HandleFunc("GET /drive/v3/files/{fileId}/permissions", h.listPermissions)
+	h.mux.HandleFunc("GET /drive/v3/files/{fileId}/revisions/{revisionId}/export", h.exportRevision)
+	h.mux.HandleFunc("GET /drive/v3/drives/{driveId}", h.getDrive)
+	h.mux.HandleFunc("GET /drive/v3/changes/startPageToken", h.startPageToken)
+	h.mux.HandleFunc("GET /drive/v3/changes", h.listChanges)
+	h.mux.HandleFunc("POST /drive/v3/files/{fileId}/watch", h.watchFile)
+	h.mux.HandleFunc("POST /drive/v3/changes/watch", h.watchChanges)
+	h.mux.HandleFunc("POST /drive/v3/channels/stop", h.stopChannel)
+	h.mux.HandleFunc("GET /v1/documents/{documentId}", h.getDocument)
+	h.mux.HandleFunc("POST /v1/documents/{documentAction}", h.batchUpdate)
+	h.mux.HandleFunc("GET /v2/labels/{labelId}", h.getLabel)
+	h.mux.HandleFunc("POST /v2/activity:query", h.queryActivity)
+	h.mux.HandleFunc("GET /thumbnails/{fileId}", h.thumbnail)
+
+	return h, nil
+}
+
+// NewServer starts a local server for the fixtures in dir
+func NewServer(logger *slog.Logger, dir string) (*Server, error) {
+	h, err := NewHandler(logger, dir)
+	if err != nil {
+		return nil, err
+	}
+	return &Server{Server: httptest.NewServer(h), Handler: h}, nil
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.Logger.Debug("request", "method", r.Method, "path", r.URL.Path, "query", r.URL.RawQuery)
+	h.mux.ServeHTTP(w, r)
+}
+
+// Files returns all files known to the fake, sorted by name
+func (h *Handler) Files() []*drive.File {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	files := make([]*drive.File, 0, len(h.fixtures))
+	for _, f := range h.fixtures {
+		file := *f.File
+		files = append(files, &file)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].Name == files[j].Name {
+			return files[i].Id < files[j].Id
+		}
+		return files[i].Name < files[j].Name
+	})
+	return files
+}
+
+// Updates returns the batch update requests received for a document
+func (h *Handler) Updates(docID string) []*docs.Request {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.updates[docID]
+}
+
+// Touch bumps the modified time of a file and records the change
+func (h *Handler) Touch(fileID string) {
+	h.mutate(fileID, func(f *drive.File) {
+		f.ModifiedTime = time.Now().UTC().Format(time.RFC3339)
+	})
+}
+
+// Trash moves a file to the trash and records the change
+func (h *Handler) Trash(fileID string) {
+	h.mutate(fileID, func(f *drive.File) {
+		f.Trashed = true
+	})
+}
+
+// Move replaces the parents of a file and records the change
+func (h *Handler) Move(fileID string, parentIDs ...string) {
+	h.mutate(fileID, func(f *drive.File) {
+		f.Parents = parentIDs
+	})
+}
+
+// mutate updates a file, appends a change with a copy of it to the changes feed and
+// notifies the channels watching the file or the changes feed
+func (h *Handler) mutate(fileID string, update func(f *drive.File)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	f, ok := h.fixtures[fileID]
+	if !ok {
+		return
+	}
+	update(f.File)
+	f.File.Version++
+
+	changed := *f.File
+	h.changes = append(h.changes, &drive.Change{
+		ChangeType: "file",
+		FileId:     fileID,
+		File:       &changed,
+		Time:       time.Now().UTC().Format(time.RFC3339),
+	})
+
+	for _, w := range h.watches {
+		switch {
+		case w.FileID == "":
+			go h.notify(w.Channel, google.ResourceStateChange)
+		case w.FileID == fileID && changed.Trashed:
+			go h.notify(w.Channel, google.ResourceStateTrash)
+		case w.FileID == fileID:
+			go h.notify(w.Channel, google.ResourceStateUpdate)
+		}
+	}
+}
+
+// notify delivers a push notification to a channel address
+func (h *Handler) notify(channel drive.Channel, state string) {
+	req, err := http.NewRequest(http.MethodPost, channel.Address, nil)
+	if err != nil {
+		h.Logger.Error("failed to create notification", "channel_id", channel.Id, "error", err.Error())
+		return
+	}
+	req.Header.Set(google.HeaderChannelID, channel.Id)
+	req.Header.Set(google.HeaderChannelToken, channel.Token)
+	req.Header.Set(google.HeaderResourceID, channel.ResourceId)
+	req.Header.Set(google.HeaderResourceState, state)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		h.Logger.Error("failed to deliver notification", "channel_id", channel.Id, "error", err.Error())
+		return
+	}
+	resp.Body.Close()
+}
+
+func (h *Handler) fixture(id string) (*fixture, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	f, ok := h.fixtures[id]
+	return f, ok
+}
+
+func (h *Handler) about(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, &drive.About{User: &drive.User{
+		DisplayName:  "Fake Service Account",
+		EmailAddress: "fake@specs.local",
+	}})
+}
+
+func (h *Handler) listFiles(w http.ResponseWriter, r *http.Request) {
+	match, err := parseQuery(r.URL.Query().Get("q"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid Value: "+err.Error())
+		return
+	}
+
+	pageSize := defaultPageSize
+	if v := r.URL.Query().Get("pageSize"); v != "" {
+		if pageSize, err = strconv.Atoi(v); err != nil || pageSize <= 0 {
+			writeError(w, http.StatusBadRequest, "Invalid pageSize")
+			return
+		}
+	}
+	offset := 0
+	if v := r.URL.Query().Get("pageToken"); v != "" {
+		if offset, err = strconv.Atoi(v); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid pageToken")
+			return
+		}
+	}
+
+	// a shared drive corpus only holds the files of that drive, its root excluded
+	driveID := r.URL.Query().Get("driveId")
+	if r.URL.Query().Get("corpora") == google.CorporaDrive && driveID == "" {
+		writeError(w, http.StatusBadRequest, "The driveId parameter must be specified")
+		return
+	}
+
+	var matched []*drive.File
+	for _, f := range h.Files() {
+		if f.Id == RootFolderID || !match(f) {
+			continue
+		}
+		if driveID != "" && (f.DriveId != driveID || f.Id == driveID) {
+			continue
+		}
+		matched = append(matched, withLabels(r, withThumbnail(r, f)))
+	}
+
+	list := &drive.FileList{Files: []*drive.File{}}
+	if offset < len(matched) {
+		end := min(offset+pageSize, len(matched))
+		list.Files = matched[offset:end]
+		if end < len(matched) {
+			list.NextPageToken = strconv.Itoa(end)
+		}
+	}
+
+	writeJSON(w, list)
+}
+
+func (h *Handler) startPageToken(w http.ResponseWriter, r *http.Request) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	writeJSON(w, &drive.StartPageToken{StartPageToken: strconv.Itoa(len(h.changes))})
+}
+
+func (h *Handler) listChanges(w http.ResponseWriter, r *http.Request) {
+	offset, err := strconv.Atoi(r.URL.Query().Get("pageToken"))
+	if err != nil || offset < 0 {
+		writeError(w, http.StatusBadRequest, "Invalid pageToken")
+		return
+	}
+	pageSize := defaultPageSize
+	if v := r.URL.Query().Get("pageSize"); v != "" {
+		if pageSize, err = strconv.Atoi(v); err != nil || pageSize <= 0 {
+			writeError(w, http.StatusBadRequest, "Invalid pageSize")
+			return
+		}
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	list := &drive.ChangeList{Changes: []*drive.Change{}}
+	if offset < len(h.changes) {
+		end := min(offset+pageSize, len(h.changes))
+		for _, change := range h.changes[offset:end] {
+			if change.File != nil {
+				withLink := *change
+				withLink.File = withLabels(r, withThumbnail(r, change.File))
+				change = &withLink
+			}
+			list.Changes = append(list.Changes, change)
+		}
+		offset = end
+	}
+	if offset < len(h.changes) {
+		list.NextPageToken = strconv.Itoa(offset)
+	} else {
+		list.NewStartPageToken = strconv.Itoa(len(h.changes))
+	}
+
+	writeJSON(w, list)
+}
+
+func (h *Handler) watchFile(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.fixture(r.PathValue("fileId")); !ok {
+		writeError(w, http.StatusNotFound, "File not found: "+r.PathValue("fileId"))
+		return
+	}
+	h.openWatch(w, r, r.PathValue("fileId"))
+}
+
+func (h *Handler) watchChanges(w http.ResponseWriter, r *http.Request) {
+	h.openWatch(w, r, "")
+}
+
+// openWatch registers a channel and sends it the initial sync notification
+func (h *Handler) openWatch(w http.ResponseWriter, r *http.Request, fileID string) {
+	var channel drive.Channel
+	if err := json.NewDecoder(r.Body).Decode(&channel); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if channel.Id == "" || channel.Address == "" {
+		writeError(w, http.StatusBadRequest, "Channel id and address are required")
+		return
+	}
+
+	channel.Kind = "api#channel"
+	channel.ResourceId = "resource-" + fileID
+	if fileID == "" {
+		channel.ResourceId = "resource-changes"
+	}
+	if channel.Expiration == 0 {
+		channel.Expiration = time.Now().Add(time.Hour).UnixMilli()
+	}
+
+	h.mu.Lock()
+	h.watches[channel.Id] = &watch{Channel: channel, FileID: fileID}
+	h.mu.Unlock()
+
+	go h.notify(channel, google.ResourceStateSync)
+	writeJSON(w, &channel)
+}
+
+func (h *Handler) stopChannel(w http.ResponseWriter, r *http.Request) {
+	var channel drive.Channel
+	if err := json.NewDecoder(r.Body).Decode(&channel); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.mu.Lock()
+	_, ok := h.watches[channel.Id]
+	delete(h.watches, channel.Id)
+	h.mu.Unlock()
+
+	if !ok {
+		writeError(w, http.StatusNotFound, "Channel not found: "+channel.Id)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) getFile(w http.ResponseWriter, r *http.Request) {
+	f, ok := h.fixture(r.PathValue("fileId"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "File not found: "+r.PathValue("fileId"))
+		return
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if r.URL.Query().Get("alt") == "media" {
+		if f.Content == nil || f.File.MimeType == google.MimeTypeSheet {
+			writeError(w, http.StatusForbidden, "Only files with binary content can be downloaded. Use Export with Docs Editors files.")
+			return
+		}
+		w.Header().Set("Content-Type", f.File.MimeType)
+		_, _ = w.Write(f.Content)
+		return
+	}
+
+	writeJSON(w, withLabels(r, withThumbnail(r, f.File)))
+}
+
+// updateFile merges the appProperties of the request into a file. The modified time is
+// taken from the request when set and bumped otherwise, other fields are ignored.
+func (h *Handler) updateFile(w http.ResponseWriter, r *http.Request) {
+	fileID := r.PathValue("fileId")
+	if _, ok := h.fixture(fileID); !ok {
+		writeError(w, http.StatusNotFound, "File not found: "+fileID)
+		return
+	}
+
+	var update drive.File
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.mutate(fileID, func(f *drive.File) {
+		// copies of the file recorded in the changes feed share the previous map
+		properties := map[string]string{}
+		maps.Copy(properties, f.AppProperties)
+		maps.Copy(properties, update.AppProperties)
+		f.AppProperties = properties
+		f.ModifiedTime = update.ModifiedTime
+		if f.ModifiedTime == "" {
+			f.ModifiedTime = time.Now().UTC().Format(time.RFC3339)
+		}
+	})
+
+	f, _ := h.fixture(fileID)
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	writeJSON(w, withLabels(r, withThumbnail(r, f.File)))
+}
+
+// getDrive serves the folders standing for the root of a shared drive
+func (h *Handler) getDrive(w http.ResponseWriter, r *http.Request) {
+	driveID := r.PathValue("driveId")
+	f, ok := h.fixture(driveID)
+	if !ok || f.File.DriveId != driveID {
+		writeError(w, http.StatusNotFound, "Shared drive not found: "+driveID)
+		return
+	}
+	writeJSON(w, &drive.Drive{Id: driveID, Name: f.File.Name})
+}
+
+func (h *Handler) exportFile(w http.ResponseWriter, r *http.Request) {
+	f, ok := h.fixture(r.PathValue("fileId"))
+	if !ok || (f.Document == nil && f.File.MimeType != google.MimeTypeSheet) {
+		writeError(w, http.StatusNotFound, "File not found: "+r.PathValue("fileId"))
+		return
+	}
+
+	mimeType := r.URL.Query().Get("mimeType")
+	if f.File.MimeType == google.MimeTypeSheet {
+		if mimeType == google.MimeTypePDF {
+			w.Header().Set("Content-Type", mimeType)
+			_, _ = w.Write(renderPDF(string(f.Content)))
+			return
+		}
+		if mimeType != google.MimeTypeCSV {
+			writeError(w, http.StatusBadRequest, "Export format not supported by fake: "+mimeType)
+			return
+		}
+		w.Header().Set("Content-Type", mimeType)
+		_, _ = w.Write(f.Content)
+		return
+	}
+
+	switch mimeType {
+	case google.MimeTypeHTML:
+		w.Header().Set("Content-Type", mimeType)
+		_, _ = w.Write([]byte(f.HTML))
+	case "text/plain", google.MimeTypePDF:
+		doc, err := goquery.NewDocumentFromReader(strings.NewReader(f.HTML))
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		w.Header().Set("Content-Type", mimeType)
+		if mimeType == google.MimeTypePDF {
+			_, _ = w.Write(renderPDF(doc.Text()))
+			return
+		}
+		_, _ = w.Write([]byte(doc.Text()))
+	default:
+		writeError(w, http.StatusBadRequest, "Export format not supported by fake: "+mimeType)
+	}
+}
+
+// revisions returns the past revisions of a Doc followed by its current content
+func (h *Handler) revisions(f *fixture) []*revision {
+	head := &revision{
+		Revision: drive.Revision{
+			Id:           strconv.Itoa(len(f.Revisions) + 1),
+			ModifiedTime: f.File.ModifiedTime,
+		},
+		HTML: f.HTML,
+	}
+	if len(f.Revisions) > 0 {
+		if last, err := strconv.Atoi(f.Revisions[len(f.Revisions)-1].Id); err == nil {
+			head.Id = strconv.Itoa(last + 1)
+		}
+	}
+	return append(f.Revisions[:len(f.Revisions):len(f.Revisions)], head)
+}
+
+// listRevisions lists the revisions of a Doc with export links pointing back at the
+// fake, revisions without HTML having none. Other files have a single revision.
+func (h *Handler) listRevisions(w http.ResponseWriter, r *http.Request) {
+	f, ok := h.fixture(r.PathValue("fileId"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "File not found: "+r.PathValue("fileId"))
+		return
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	list := &drive.RevisionList{}
+	if f.Document == nil {
+		list.Revisions = []*drive.Revision{{Id: "1", ModifiedTime: f.File.ModifiedTime}}
+		writeJSON(w, list)
+		return
+	}
+
+	for _, rev := range h.revisions(f) {
+		listed := rev.Revision
+		if rev.HTML != "" {
+			listed.ExportLinks = map[string]string{
+				google.MimeTypeHTML: fmt.Sprintf("http://%s/drive/v3/files/%s/revisions/%s/export?mimeType=%s",
+					r.Host, f.File.Id, rev.Id, url.QueryEscape(google.MimeTypeHTML)),
+			}
+		}
+		list.Revisions = append(list.Revisions, &listed)
+	}
+	writeJSON(w, list)
+}
+
+func (h *Handler) exportRevision(w http.ResponseWriter, r *http.Request) {
+	f, ok := h.fixture(r.PathValue("fileId"))
+	if !ok || f.Document == nil {
+		writeError(w, http.StatusNotFound, "File not found: "+r.PathValue("fileId"))
+		return
+	}
+	if mimeType := r.URL.Query().Get("mimeType"); mimeType != google.MimeTypeHTML {
+		writeError(w, http.StatusBadRequest, "Export format not supported by fake: "+mimeType)
+		return
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for _, rev := range h.revisions(f) {
+		if rev.Id == r.PathValue("revisionId") && rev.HTML != "" {
+			w.Header().Set("Content-Type", google.MimeTypeHTML)
+			_, _ = w.Write([]byte(rev.HTML))
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "Revision not found: "+r.PathValue("revisionId"))
+}
+
+// listPermissions serves the permissions set in the meta file of a fixture
+func (h *Handler) listPermissions(w http.ResponseWriter, r *http.Request) {
+	f, ok := h.fixture(r.PathValue("fileId"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "File not found: "+r.PathValue("fileId"))
+		return
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	list := &drive.PermissionList{Permissions: []*drive.Permission{}}
+	list.Permissions = append(list.Permissions, f.File.Permissions...)
+	writeJSON(w, list)
+}
+
+func (h *Handler) listComments(w http.ResponseWriter, r *http.Request) {
+	f, ok := h.fixture(r.PathValue("fileId"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "File not found: "+r.PathValue("fileId"))
+		return
+	}
+	if r.URL.Query().Get("fields") == "" {
+		writeError(w, http.StatusBadRequest, "The 'fields' parameter is required for this method.")
+		return
+	}
+
+	var err error
+	pageSize := 20
+	if v := r.URL.Query().Get("pageSize"); v != "" {
+		if pageSize, err = strconv.Atoi(v); err != nil || pageSize <= 0 || pageSize > 100 {
+			writeError(w, http.StatusBadRequest, "Invalid pageSize")
+			return
+		}
+	}
+	offset := 0
+	if v := r.URL.Query().Get("pageToken"); v != "" {
+		if offset, err = strconv.Atoi(v); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid pageToken")
+			return
+		}
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	includeDeleted := r.URL.Query().Get("includeDeleted") == "true"
+	var comments []*drive.Comment
+	for _, comment := range f.Comments {
+		if includeDeleted || !comment.Deleted {
+			comments = append(comments, comment)
+		}
+	}
+
+	list := &drive.CommentList{Comments: []*drive.Comment{}}
+	if offset < len(comments) {
+		end := min(offset+pageSize, len(comments))
+		list.Comments = comments[offset:end]
+		if end < len(comments) {
+			list.NextPageToken = strconv.Itoa(end)
+		}
+	}
+	writeJSON(w, list)
+}
+
+func (h *Handler) getDocument(w http.ResponseWriter, r *http.Request) {
+	f, ok := h.fixture(r.PathValue("documentId"))
+	if !ok || f.Document == nil {
+		writeError(w, http.StatusNotFound, "Requested entity was not found.")
+		return
+	}
+	writeJSON(w, withTabs(r, f.Document))
+}
+
+// batchUpdate records the requests, bumps the file's modified time and records an edit
+// by the fake service account. The requests are not applied to the fixture content.
+func (h *Handler) batchUpdate(w http.ResponseWriter, r *http.Request) {
+	docID, ok := strings.CutSuffix(r.PathValue("documentAction"), ":batchUpdate")
+	if !ok {
+		writeError(w, http.StatusNotFound, "Unknown document action")
+		return
+	}
+
+	var req docs.BatchUpdateDocumentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.mu.Lock()
+	f, ok := h.fixtures[docID]
+	if ok {
+		h.updates[docID] = append(h.updates[docID], req.Requests...)
+		h.recordEdit(f)
+	}
+	h.mu.Unlock()
+	if ok {
+		h.Touch(docID)
+	}
+
+	if !ok {
+		writeError(w, http.StatusNotFound, "Requested entity was not found.")
+		return
+	}
+
+	writeJSON(w, &docs.BatchUpdateDocumentResponse{
+		DocumentId: docID,
+		Replies:    make([]*docs.Response, len(req.Requests)),
+	})
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes an error in the format returned by Google APIs, so clients
+// surface it as a *googleapi.Error
+func writeError(w http.ResponseWriter, code int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"error": map[string]any{
+			"code":    code,
+			"message": message,
+		},
+	})
+}
@@ -0,0 +1,57 @@
+package fake
+
+import (
+	"fmt"
+	"hash/fnv"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+
+	"github.com/canonical/specs-v2.canonical.com/google"
+	"google.golang.org/api/drive/v3"
+)
+
+// withThumbnail returns a copy of a file with a thumbnailLink pointing back at the fake,
+// as Drive sets for every file but folders and shortcuts
+func withThumbnail(r *http.Request, file *drive.File) *drive.File {
+	withLink := *file
+	if file.MimeType != google.MimeTypeFolder && file.MimeType != google.MimeTypeShortcut {
+		withLink.ThumbnailLink = fmt.Sprintf("http://%s/thumbnails/%s?v=%d", r.Host, file.Id, file.Version)
+	}
+	return &withLink
+}
+
+// thumbnail serves a page-shaped PNG tinted after the file ID and version, so a new
+// version of a file gets a different thumbnail
+func (h *Handler) thumbnail(w http.ResponseWriter, r *http.Request) {
+	f, ok := h.fixture(r.PathValue("fileId"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "File not found: "+r.PathValue("fileId"))
+		return
+	}
+
+	h.mu.RLock()
+	hash := fnv.New32a()
+	fmt.Fprintf(hash, "%s/%d", f.File.Id, f.File.Version)
+	h.mu.RUnlock()
+	sum := hash.Sum32()
+	tint := color.RGBA{R: uint8(sum), G: uint8(sum >> 8), B: uint8(sum >> 16), A: 255}
+
+	img := image.NewRGBA(image.Rect(0, 0, 170, 220))
+	for y := 0; y < 220; y++ {
+		for x := 0; x < 170; x++ {
+			c := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+			switch {
+			case y < 40:
+				c = tint
+			case y%16 == 0 && x > 15 && x < 155:
+				c = color.RGBA{R: 200, G: 200, B: 200, A: 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	_ = png.Encode(w, img)
+}
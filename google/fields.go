@@ -12,12 +12,15 @@ type FieldBuilder struct {
 
 const (
 	// Basic file fields
-	FieldID          = "id"
-	FieldName        = "name"
-	FieldMimeType    = "mimeType"
-	FieldSize        = "size"
-	FieldWebViewLink = "webViewLink"
-	FieldWebContent  = "webContentLink"
+	FieldID            = "id"
+	FieldName          = "name"
+	FieldMimeType      = "mimeType"
+	FieldSize          = "size"
+	FieldWebViewLink   = "webViewLink"
+	FieldWebContent    = "webContentLink"
+	FieldDescription   = "description"
+	FieldThumbnailLink = "thumbnailLink"
+	FieldVersion       = "version"
 
 	// Time-related fields
 	FieldCreatedTime    = "createdTime"
@@ -32,6 +35,9 @@ const (
 	FieldShared       = "shared"
 	FieldSharedWithMe = "sharedWithMe"
 	FieldSharingUser  = "sharingUser"
+	FieldWriters      = "writers"
+	FieldReaders      = "readers"
+	FieldVisibility   = "visibility"
 
 	// Special fields
 	FieldNextPageToken = "nextPageToken"
@@ -40,6 +46,44 @@ const (
 	FieldTrashed       = "trashed"
 	FieldOwner         = "owner"
 	FieldFullText      = "fullText"
+	FieldStarred       = "starred"
+	FieldProperties    = "properties"
+	FieldAppProperties = "appProperties"
+	FieldDriveID       = "driveId"
+	FieldLabelInfo     = "labelInfo"
+
+	// Change fields
+	FieldChanges           = "changes"
+	FieldChangeType        = "changeType"
+	FieldRemoved           = "removed"
+	FieldFileID            = "fileId"
+	FieldFile              = "file"
+	FieldNewStartPageToken = "newStartPageToken"
+
+	// Revision fields
+	FieldRevisions         = "revisions"
+	FieldExportLinks       = "exportLinks"
+	FieldLastModifyingUser = "lastModifyingUser"
+	FieldDisplayName       = "displayName"
+	FieldEmailAddress      = "emailAddress"
+
+	// Permission fields
+	FieldType               = "type"
+	FieldRole               = "role"
+	FieldDomain             = "domain"
+	FieldAllowFileDiscovery = "allowFileDiscovery"
+
+	// Comment fields
+	FieldComments = "comments"
+	FieldReplies  = "replies"
+	FieldAuthor   = "author"
+	FieldResolved = "resolved"
+	FieldDeleted  = "deleted"
+
+	// Shortcut fields
+	FieldShortcutDetails = "shortcutDetails"
+	FieldTargetID        = "targetId"
+	FieldTargetMimeType  = "targetMimeType"
 )
 
 func NewFieldBuilder() *FieldBuilder {
@@ -45,6 +45,15 @@ func (g *Google) ListFilesChannel(ctx context.Context, opts QueryOptions) <-chan
 			if opts.IncludeItemsFromAllDrives {
 				call = call.IncludeItemsFromAllDrives(true)
 			}
+			if opts.Corpora != "" {
+				call = call.Corpora(opts.Corpora)
+			}
+			if opts.DriveID != "" {
+				call = call.DriveId(opts.DriveID)
+			}
+			if len(g.IncludeLabels) > 0 {
+				call = call.IncludeLabels(g.includeLabels())
+			}
 			if pageToken != "" {
 				call = call.PageToken(pageToken)
 			}
@@ -76,12 +85,23 @@ func (g *Google) ListFilesChannel(ctx context.Context, opts QueryOptions) <-chan
 	return resultChan
 }
 
+// errorChannel returns a closed channel holding a single error result
+func errorChannel(err error) <-chan FileResult {
+	resultChan := make(chan FileResult, 1)
+	resultChan <- FileResult{Err: fmt.Errorf("invalid query: %w", err)}
+	close(resultChan)
+	return resultChan
+}
+
 // GetSubFoldersChannel streams subfolders of the provided folder ID through a channel
 func (g *Google) GetSubFoldersChannel(ctx context.Context, folderID string) <-chan FileResult {
 	qb := NewQueryBuilder()
-	query := qb.IsFolder().
+	query, err := qb.IsFolder().
 		InParent(folderID).
 		Build()
+	if err != nil {
+		return errorChannel(err)
+	}
 
 	fb := NewFieldBuilder()
 	fields := fb.Pagination().
@@ -101,10 +121,13 @@ func (g *Google) GetSubFoldersChannel(ctx context.Context, folderID string) <-ch
 // GetFilesInFolderChannel streams files in the provided folder ID through a channel
 func (g *Google) GetFilesInFolderChannel(ctx context.Context, folderID string) <-chan FileResult {
 	qb := NewQueryBuilder()
-	query := qb.NotTrashed().
+	query, err := qb.NotTrashed().
 		InParent(folderID).
 		MimeType(MimeTypeDocument).
 		Build()
+	if err != nil {
+		return errorChannel(err)
+	}
 
 	fb := NewFieldBuilder()
 	fields := fb.Pagination().
@@ -126,6 +149,153 @@ func (g *Google) GetFilesInFolderChannel(ctx context.Context, folderID string) <
 	return g.ListFilesChannel(ctx, opts)
 }
 
+// documentFields are the file fields needed to sync a Google Doc
+var documentFields = []string{
+	FieldID,
+	FieldName,
+	FieldMimeType,
+	FieldParents,
+	FieldModifiedTime,
+	FieldCreatedTime,
+	FieldWebViewLink,
+	FieldTrashed,
+	FieldDriveID,
+	FieldDescription,
+	FieldThumbnailLink,
+	FieldVersion,
+	FieldAppProperties,
+	FieldLabelInfo,
+	permissionFields,
+}
+
+// permissionFields are the fields of the permissions of a file, which Drive only returns
+// to users allowed to share it, and never in shared drives
+var permissionFields = NewFieldBuilder().
+	SubFields(FieldPermissions, permissionSubFields...).
+	Build()
+
+var permissionSubFields = []string{
+	FieldID,
+	FieldType,
+	FieldRole,
+	FieldEmailAddress,
+	FieldDomain,
+	FieldDisplayName,
+	FieldAllowFileDiscovery,
+}
+
+// GetFolderChildrenChannel streams the subfolders, shortcuts and files of the given MIME
+// types (Google Docs when none are given) in the provided folder ID through a channel.
+// The folder is searched for in the given shared drive, or in the user corpus when
+// driveID is empty.
+func (g *Google) GetFolderChildrenChannel(
+	ctx context.Context,
+	folderID, driveID string,
+	mimeTypes ...string,
+) <-chan FileResult {
+	if len(mimeTypes) == 0 {
+		mimeTypes = []string{MimeTypeDocument}
+	}
+
+	conditions := []*QueryBuilder{
+		NewQueryBuilder().IsFolder(),
+		NewQueryBuilder().MimeType(MimeTypeShortcut),
+	}
+	for _, mimeType := range mimeTypes {
+		conditions = append(conditions, NewQueryBuilder().MimeType(mimeType))
+	}
+
+	qb := NewQueryBuilder()
+	query, err := qb.NotTrashed().
+		InParent(folderID).
+		Or(conditions...).
+		Build()
+	if err != nil {
+		return errorChannel(err)
+	}
+
+	shortcutFields := NewFieldBuilder().
+		SubFields(FieldShortcutDetails, FieldTargetID, FieldTargetMimeType).
+		Build()
+
+	fb := NewFieldBuilder()
+	fields := fb.Pagination().
+		SubFields(FieldFiles, append(documentFields, shortcutFields)...).
+		Build()
+
+	opts := QueryOptions{
+		Query:                     query,
+		Fields:                    fields,
+		SupportsAllDrives:         true,
+		IncludeItemsFromAllDrives: true,
+	}
+	if driveID != "" {
+		opts.Corpora = CorporaDrive
+		opts.DriveID = driveID
+	}
+
+	return g.ListFilesChannel(ctx, opts)
+}
+
+// GetDrive fetches the metadata of a shared drive
+func (g *Google) GetDrive(ctx context.Context, driveID string) (*drive.Drive, error) {
+	return g.DriveService.Drives.Get(driveID).
+		Context(ctx).
+		Fields(googleapi.Field(NewFieldBuilder().AddFields(FieldID, FieldName).Build())).
+		Do()
+}
+
+// GetFile fetches the metadata of a single file, e.g. the target of a shortcut
+func (g *Google) GetFile(ctx context.Context, fileID string) (*drive.File, error) {
+	fields := NewFieldBuilder().AddFields(documentFields...).Build()
+
+	call := g.DriveService.Files.Get(fileID).
+		Context(ctx).
+		Fields(googleapi.Field(fields)).
+		SupportsAllDrives(true)
+	if len(g.IncludeLabels) > 0 {
+		call = call.IncludeLabels(g.includeLabels())
+	}
+	return call.Do()
+}
+
+// ListPermissions lists the permissions of a file, including files in shared drives
+func (g *Google) ListPermissions(ctx context.Context, fileID string) ([]*drive.Permission, error) {
+	fields := NewFieldBuilder().
+		Pagination().
+		SubFields(FieldPermissions, permissionSubFields...).
+		Build()
+
+	var permissions []*drive.Permission
+	err := g.DriveService.Permissions.List(fileID).
+		Context(ctx).
+		Fields(googleapi.Field(fields)).
+		SupportsAllDrives(true).
+		Pages(ctx, func(list *drive.PermissionList) error {
+			permissions = append(permissions, list.Permissions...)
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+	return permissions, nil
+}
+
+// DownloadFile downloads the content of a file uploaded to Drive, such as a PDF. Google
+// Docs, Sheets and Slides have no content of their own and must be exported instead.
+func (g *Google) DownloadFile(ctx context.Context, fileID string) ([]byte, error) {
+	resp, err := g.DriveService.Files.Get(fileID).
+		Context(ctx).
+		SupportsAllDrives(true).
+		Download()
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return io.ReadAll(resp.Body)
+}
+
 // ExportFile exports a Google Doc to markdown format
 func (g *Google) ExportFile(ctx context.Context, fileID string, format string) (string, error) {
 	resp, err := g.DriveService.Files.Export(fileID, format).Context(ctx).Download()
@@ -144,17 +314,18 @@ func (g *Google) ExportFile(ctx context.Context, fileID string, format string) (
 	return markdown, nil
 }
 
-// DocumentFirstTable extracts the first table from a Google Document and converts it into a 2D string array.
+// DocumentMetadataTable extracts the metadata table from a Google Document and converts it into a 2D string array.
 // It takes a context and a file ID as input parameters and returns a 2D slice of strings representing
 // the table's content.
 //
 // The function performs the following steps:
 // 1. Exports the Google Document as HTML using the provided file ID.
-// 2. Parses the HTML content to find the first table element.
-// 3. Iterates over each row ("tr") in the table.
+// 2. Parses the HTML content to find the top level table elements.
+// 3. Iterates over each row ("tr") of each table.
 // 4. For each row, extracts the text content from each cell ("th" and "td").
-// 5. If a cell contains mailto links, it extracts the email addresses and joins them with commas.
+// 5. If a cell contains mailto links, it formats them as people (see Person.String) and joins them with commas.
 // 6. Appends the extracted row data to the result slice.
+// 7. Picks the metadata table among them with MetadataTableIndex, like DocumentTable.
 //
 // If no table is found or no data could be extracted, it returns an error.
 //
@@ -165,23 +336,43 @@ func (g *Google) ExportFile(ctx context.Context, fileID string, format string) (
 // Returns:
 // - A 2D slice of strings representing the table's content.
 // - An error if any issues occur during the extraction process.
-func (g *Google) DocumentFirstTable(ctx context.Context, fileID string) ([][]string, error) {
+func (g *Google) DocumentMetadataTable(ctx context.Context, fileID string) ([][]string, error) {
 	content, err := g.ExportFile(ctx, fileID, MimeTypeHTML)
 	if err != nil {
 		return nil, err
 	}
+	return htmlMetadataTable(content)
+}
 
+// htmlMetadataTable extracts the metadata table of the HTML export of a Google Document,
+// see DocumentMetadataTable
+func htmlMetadataTable(content string) ([][]string, error) {
 	doc, err := goquery.NewDocumentFromReader(strings.NewReader(content))
 	if err != nil {
 		return nil, err
 	}
 
-	table := doc.Find("table").First()
+	var tables [][][]string
+	doc.Find("table").Each(func(i int, table *goquery.Selection) {
+		// nested tables are part of the cells of their parent
+		if table.ParentsFiltered("table").Length() == 0 {
+			tables = append(tables, htmlTableRows(table))
+		}
+	})
 
-	if table.Length() == 0 {
+	index := MetadataTableIndex(tables)
+	if index < 0 {
 		return nil, fmt.Errorf("no table found in the document")
 	}
+	if len(tables[index]) == 0 {
+		return nil, fmt.Errorf("table found but no data could be extracted")
+	}
 
+	return tables[index], nil
+}
+
+// htmlTableRows extracts the cells of a table of the HTML export of a Google Document
+func htmlTableRows(table *goquery.Selection) [][]string {
 	var result [][]string
 
 	table.Find("tr").Each(func(i int, row *goquery.Selection) {
@@ -194,8 +385,10 @@ func (g *Google) DocumentFirstTable(ctx context.Context, fileID string) ([][]str
 			if mailtoLinks.Length() > 0 {
 				var authors []string
 				mailtoLinks.Each(func(k int, link *goquery.Selection) {
-					authorName := strings.TrimSpace(link.Text())
-					authors = append(authors, authorName)
+					authors = append(authors, Person{
+						Name:  strings.TrimSpace(link.Text()),
+						Email: strings.TrimPrefix(link.AttrOr("href", ""), "mailto:"),
+					}.String())
 				})
 				if len(authors) > 0 {
 					rowData = append(rowData, strings.Join(authors, ","))
@@ -213,9 +406,5 @@ func (g *Google) DocumentFirstTable(ctx context.Context, fileID string) ([][]str
 		}
 	})
 
-	if len(result) == 0 {
-		return nil, fmt.Errorf("table found but no data could be extracted")
-	}
-
-	return result, nil
+	return result
 }
@@ -0,0 +1,74 @@
+package google
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"google.golang.org/api/drive/v3"
+)
+
+// labelViewFull returns the fields of a label with their selection choices, the basic
+// view only has the label properties
+const labelViewFull = "LABEL_VIEW_FULL"
+
+// LabelChoices returns the display names of the selection choices of a Drive label, by
+// field ID then choice ID. Files only carry the choice IDs of the labels applied to them.
+func (g *Google) LabelChoices(ctx context.Context, labelID string) (map[string]map[string]string, error) {
+	label, err := g.LabelsService.Labels.Get("labels/" + labelID).
+		Context(ctx).
+		View(labelViewFull).
+		Do()
+	if err != nil {
+		return nil, err
+	}
+
+	choices := map[string]map[string]string{}
+	for _, field := range label.Fields {
+		if field.SelectionOptions == nil {
+			continue
+		}
+		names := map[string]string{}
+		for _, choice := range field.SelectionOptions.Choices {
+			if choice.Properties != nil {
+				names[choice.Id] = choice.Properties.DisplayName
+			}
+		}
+		choices[field.Id] = names
+	}
+	return choices, nil
+}
+
+// LabelFieldValues returns the values of a field of a label applied to a file, with
+// selection choices given by ID and users by email. It returns nil when the label is not
+// applied, the field is not set, or the label was not requested in IncludeLabels.
+func LabelFieldValues(file *drive.File, labelID, fieldID string) []string {
+	if file.LabelInfo == nil {
+		return nil
+	}
+	for _, label := range file.LabelInfo.Labels {
+		if label.Id != labelID {
+			continue
+		}
+		field, ok := label.Fields[fieldID]
+		if !ok {
+			return nil
+		}
+
+		values := append([]string{}, field.Text...)
+		values = append(values, field.Selection...)
+		values = append(values, field.DateString...)
+		for _, n := range field.Integer {
+			values = append(values, strconv.FormatInt(n, 10))
+		}
+		for _, user := range field.User {
+			values = append(values, user.EmailAddress)
+		}
+		return values
+	}
+	return nil
+}
+
+func (g *Google) includeLabels() string {
+	return strings.Join(g.IncludeLabels, ",")
+}
@@ -0,0 +1,33 @@
+package google
+
+import (
+	"context"
+
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+)
+
+// UpdateAppProperties sets private properties of a file, only visible to this
+// application's OAuth client. Properties that are not given are left unchanged. The
+// modified time of the file is set back to modifiedTime when given, so writing
+// properties is not mistaken for an edit of the content.
+func (g *Google) UpdateAppProperties(
+	ctx context.Context,
+	fileID string,
+	properties map[string]string,
+	modifiedTime string,
+) (*drive.File, error) {
+	fields := NewFieldBuilder().AddFields(documentFields...).Build()
+
+	call := g.DriveService.Files.Update(fileID, &drive.File{
+		AppProperties: properties,
+		ModifiedTime:  modifiedTime,
+	}).
+		Context(ctx).
+		Fields(googleapi.Field(fields)).
+		SupportsAllDrives(true)
+	if len(g.IncludeLabels) > 0 {
+		call = call.IncludeLabels(g.includeLabels())
+	}
+	return call.Do()
+}
@@ -1,14 +1,21 @@
 package google
 
 import (
+	"errors"
 	"fmt"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 )
 
-// QueryBuilder helps construct Google Drive search queries
+// QueryBuilder helps construct Google Drive search queries. Values are quoted and
+// escaped, and conditions comparing a field with an operator or a value type the Drive
+// query language does not support make Build fail, as leaving them out would widen the
+// query.
 type QueryBuilder struct {
 	conditions []string
+	errs       []error
 }
 
 // NewQueryBuilder creates a new QueryBuilder
@@ -23,28 +30,153 @@ const (
 	MimeTypeFolder   = "application/vnd.google-apps.folder"
 	MimeTypeDocument = "application/vnd.google-apps.document"
 	MimeTypeSheet    = "application/vnd.google-apps.spreadsheet"
+	MimeTypeShortcut = "application/vnd.google-apps.shortcut"
 	MimeTypePDF      = "application/pdf"
 	MimeTypeHTML     = "text/html"
 	MimeTypeMarkdown = "text/markdown"
+	MimeTypeCSV      = "text/csv"
+	MimeTypeDocx     = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+	// MimeTypeMarkdownX is the legacy MIME type some clients upload Markdown with
+	MimeTypeMarkdownX = "text/x-markdown"
 )
 
+// Operator compares a query term with a value
+type Operator string
+
 // Operators
 const (
-	OperatorContains  = "contains"
-	OperatorEquals    = "="
-	OperatorNotEquals = "!="
+	OperatorContains       Operator = "contains"
+	OperatorEquals         Operator = "="
+	OperatorNotEquals      Operator = "!="
+	OperatorLess           Operator = "<"
+	OperatorLessOrEqual    Operator = "<="
+	OperatorGreater        Operator = ">"
+	OperatorGreaterOrEqual Operator = ">="
+	// OperatorIn tests whether a value is in a collection, such as parents or owners
+	OperatorIn Operator = "in"
+	// OperatorHas tests whether properties or appProperties have a key and value
+	OperatorHas Operator = "has"
+)
+
+// termKind is the type of the values a query term is compared with
+type termKind int
+
+const (
+	termString termKind = iota
+	termBool
+	termTime
+	// termCollection terms are written "'<value>' in <term>"
+	termCollection
+	// termProperties terms are written "<term> has { key='<key>' and value='<value>' }"
+	termProperties
+)
+
+func (k termKind) String() string {
+	switch k {
+	case termBool:
+		return "bool"
+	case termTime:
+		return "time.Time"
+	case termProperties:
+		return "property"
+	}
+	return "string"
+}
+
+type queryTerm struct {
+	kind      termKind
+	operators []Operator
+}
+
+var (
+	stringOperators = []Operator{OperatorContains, OperatorEquals, OperatorNotEquals}
+	equalOperators  = []Operator{OperatorEquals, OperatorNotEquals}
+	timeOperators   = []Operator{
+		OperatorLess, OperatorLessOrEqual, OperatorEquals, OperatorNotEquals, OperatorGreater, OperatorGreaterOrEqual,
+	}
 )
 
+// queryTerms are the terms of the Drive query language and the operators they support,
+// see https://developers.google.com/drive/api/guides/ref-search-terms
+var queryTerms = map[string]queryTerm{
+	FieldName:           {termString, stringOperators},
+	FieldFullText:       {termString, []Operator{OperatorContains}},
+	FieldMimeType:       {termString, stringOperators},
+	FieldModifiedTime:   {termTime, timeOperators},
+	FieldCreatedTime:    {termTime, timeOperators},
+	FieldViewedByMeTime: {termTime, timeOperators},
+	FieldTrashed:        {termBool, equalOperators},
+	FieldStarred:        {termBool, equalOperators},
+	FieldSharedWithMe:   {termBool, equalOperators},
+	FieldParents:        {termCollection, []Operator{OperatorIn}},
+	FieldOwners:         {termCollection, []Operator{OperatorIn}},
+	FieldWriters:        {termCollection, []Operator{OperatorIn}},
+	FieldReaders:        {termCollection, []Operator{OperatorIn}},
+	FieldProperties:     {termProperties, []Operator{OperatorHas}},
+	FieldAppProperties:  {termProperties, []Operator{OperatorHas}},
+	FieldVisibility:     {termString, equalOperators},
+	FieldShortcutDetails + "." + FieldTargetID:       {termString, equalOperators},
+	FieldShortcutDetails + "." + FieldTargetMimeType: {termString, equalOperators},
+}
+
+// Quote returns a value as a string literal of the Drive query language, escaping
+// backslashes and single quotes
+func Quote(value string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+}
+
+// Where adds a comparison of a term with a value, a string, bool or time.Time depending
+// on the term. Collection terms such as parents only support OperatorIn, written as
+// "'<value>' in <term>". Properties are compared with HasProperty and HasAppProperty.
+func (q *QueryBuilder) Where(field string, operator Operator, value any) *QueryBuilder {
+	term, ok := queryTerms[field]
+	if !ok {
+		return q.fail(fmt.Errorf("unknown query term %q", field))
+	}
+	if !slices.Contains(term.operators, operator) {
+		return q.fail(fmt.Errorf("query term %s does not support operator %q", field, operator))
+	}
+
+	var literal string
+	switch v := value.(type) {
+	case string:
+		if term.kind != termString && term.kind != termCollection {
+			return q.fail(fmt.Errorf("query term %s takes a %s, not a string", field, term.kind))
+		}
+		literal = Quote(v)
+	case bool:
+		if term.kind != termBool {
+			return q.fail(fmt.Errorf("query term %s takes a %s, not a bool", field, term.kind))
+		}
+		literal = strconv.FormatBool(v)
+	case time.Time:
+		if term.kind != termTime {
+			return q.fail(fmt.Errorf("query term %s takes a %s, not a time", field, term.kind))
+		}
+		literal = Quote(v.UTC().Format(time.RFC3339))
+	default:
+		return q.fail(fmt.Errorf("unsupported value %v of type %T for query term %s", value, value, field))
+	}
+
+	if term.kind == termCollection {
+		return q.add(fmt.Sprintf("%s %s %s", literal, operator, field))
+	}
+	return q.add(fmt.Sprintf("%s %s %s", field, operator, literal))
+}
+
 // Name adds a name condition
-func (q *QueryBuilder) Name(operator, value string) *QueryBuilder {
-	q.conditions = append(q.conditions, fmt.Sprintf("%s %s '%s'", FieldName, operator, value))
-	return q
+func (q *QueryBuilder) Name(operator Operator, value string) *QueryBuilder {
+	return q.Where(FieldName, operator, value)
+}
+
+// FullTextContains adds a condition on the name, description and content of files
+func (q *QueryBuilder) FullTextContains(text string) *QueryBuilder {
+	return q.Where(FieldFullText, OperatorContains, text)
 }
 
 // MimeType adds a mimeType condition
 func (q *QueryBuilder) MimeType(mimeType string) *QueryBuilder {
-	q.conditions = append(q.conditions, fmt.Sprintf("%s = '%s'", FieldMimeType, mimeType))
-	return q
+	return q.Where(FieldMimeType, OperatorEquals, mimeType)
 }
 
 // IsFolder adds a condition to find folders
@@ -54,49 +186,132 @@ func (q *QueryBuilder) IsFolder() *QueryBuilder {
 
 // InParent adds a parent folder condition
 func (q *QueryBuilder) InParent(parentID string) *QueryBuilder {
-	q.conditions = append(q.conditions, fmt.Sprintf("'%s' in %s", parentID, FieldParents))
-	return q
+	return q.Where(FieldParents, OperatorIn, parentID)
+}
+
+// OwnedBy adds a condition on the owner of files
+func (q *QueryBuilder) OwnedBy(email string) *QueryBuilder {
+	return q.Where(FieldOwners, OperatorIn, email)
+}
+
+// WritableBy adds a condition on the users or groups allowed to edit files
+func (q *QueryBuilder) WritableBy(email string) *QueryBuilder {
+	return q.Where(FieldWriters, OperatorIn, email)
+}
+
+// ReadableBy adds a condition on the users or groups allowed to read files
+func (q *QueryBuilder) ReadableBy(email string) *QueryBuilder {
+	return q.Where(FieldReaders, OperatorIn, email)
+}
+
+// SharedWithMe adds a condition to find the files in the "Shared with me" collection
+func (q *QueryBuilder) SharedWithMe() *QueryBuilder {
+	return q.Where(FieldSharedWithMe, OperatorEquals, true)
 }
 
 // ModifiedAfter adds a modified time condition
 func (q *QueryBuilder) ModifiedAfter(t time.Time) *QueryBuilder {
-	q.conditions = append(q.conditions, fmt.Sprintf("%s > '%s'", FieldModifiedTime, t.Format(time.RFC3339)))
-	return q
+	return q.Where(FieldModifiedTime, OperatorGreater, t)
 }
 
 // ModifiedBefore adds a modified time condition
 func (q *QueryBuilder) ModifiedBefore(t time.Time) *QueryBuilder {
-	q.conditions = append(q.conditions, fmt.Sprintf("%s < '%s'", FieldModifiedTime, t.Format(time.RFC3339)))
-	return q
+	return q.Where(FieldModifiedTime, OperatorLess, t)
+}
+
+// CreatedAfter adds a created time condition
+func (q *QueryBuilder) CreatedAfter(t time.Time) *QueryBuilder {
+	return q.Where(FieldCreatedTime, OperatorGreater, t)
+}
+
+// CreatedBefore adds a created time condition
+func (q *QueryBuilder) CreatedBefore(t time.Time) *QueryBuilder {
+	return q.Where(FieldCreatedTime, OperatorLess, t)
 }
 
 // NotTrashed adds condition to exclude trashed files
 func (q *QueryBuilder) NotTrashed() *QueryBuilder {
-	q.conditions = append(q.conditions, fmt.Sprintf("%s = false", FieldTrashed))
-	return q
+	return q.Where(FieldTrashed, OperatorEquals, false)
+}
+
+// HasProperty adds a condition on the public custom properties of files
+func (q *QueryBuilder) HasProperty(key, value string) *QueryBuilder {
+	return q.hasProperty(FieldProperties, key, value)
+}
+
+// HasAppProperty adds a condition on the private properties this application set on files
+func (q *QueryBuilder) HasAppProperty(key, value string) *QueryBuilder {
+	return q.hasProperty(FieldAppProperties, key, value)
+}
+
+func (q *QueryBuilder) hasProperty(field, key, value string) *QueryBuilder {
+	if key == "" {
+		return q.fail(fmt.Errorf("query term %s needs a property key", field))
+	}
+	return q.add(fmt.Sprintf("%s %s { key=%s and value=%s }", field, OperatorHas, Quote(key), Quote(value)))
+}
+
+// Not adds the negation of the conditions of another builder
+func (q *QueryBuilder) Not(condition *QueryBuilder) *QueryBuilder {
+	q.errs = append(q.errs, condition.errs...)
+	switch len(condition.conditions) {
+	case 0:
+		return q
+	case 1:
+		return q.add("not " + condition.conditions[0])
+	}
+	return q.add(fmt.Sprintf("not (%s)", condition.join()))
 }
 
 // Or combines conditions with OR
 func (q *QueryBuilder) Or(conditions ...*QueryBuilder) *QueryBuilder {
-	var subQueries []string
-	for _, condition := range conditions {
-		subQueries = append(subQueries, condition.Build())
-	}
-	q.conditions = append(q.conditions, fmt.Sprintf("(%s)", strings.Join(subQueries, " or ")))
-	return q
+	return q.group(" or ", conditions)
 }
 
 // And combines conditions with AND
 func (q *QueryBuilder) And(conditions ...*QueryBuilder) *QueryBuilder {
+	return q.group(" and ", conditions)
+}
+
+// group adds the conditions joined with an operator in parentheses, leaving out empty ones
+func (q *QueryBuilder) group(operator string, conditions []*QueryBuilder) *QueryBuilder {
 	var subQueries []string
 	for _, condition := range conditions {
-		subQueries = append(subQueries, condition.Build())
+		q.errs = append(q.errs, condition.errs...)
+		switch len(condition.conditions) {
+		case 0:
+			continue
+		case 1:
+			subQueries = append(subQueries, condition.conditions[0])
+		default:
+			subQueries = append(subQueries, "("+condition.join()+")")
+		}
+	}
+	if len(subQueries) == 0 {
+		return q
 	}
-	q.conditions = append(q.conditions, fmt.Sprintf("(%s)", strings.Join(subQueries, " and ")))
+	return q.add(fmt.Sprintf("(%s)", strings.Join(subQueries, operator)))
+}
+
+func (q *QueryBuilder) add(condition string) *QueryBuilder {
+	q.conditions = append(q.conditions, condition)
+	return q
+}
+
+func (q *QueryBuilder) fail(err error) *QueryBuilder {
+	q.errs = append(q.errs, err)
 	return q
 }
 
-// Build returns the final query string
-func (q *QueryBuilder) Build() string {
+// Build returns the final query string, or the invalid conditions of the query
+func (q *QueryBuilder) Build() (string, error) {
+	if len(q.errs) > 0 {
+		return "", errors.Join(q.errs...)
+	}
+	return q.join(), nil
+}
+
+// join returns the conditions of the query joined with AND
+func (q *QueryBuilder) join() string {
 	return strings.Join(q.conditions, " and ")
 }
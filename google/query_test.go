@@ -0,0 +1,165 @@
+package google
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQueryBuilder(t *testing.T) {
+	modified := time.Date(2024, 3, 1, 12, 0, 0, 0, time.FixedZone("CET", 3600))
+
+	tests := []struct {
+		name    string
+		build   func() *QueryBuilder
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "empty",
+			build: NewQueryBuilder,
+			want:  "",
+		},
+		{
+			name:  "apostrophe",
+			build: func() *QueryBuilder { return NewQueryBuilder().Name(OperatorContains, "Jane's spec") },
+			want:  `name contains 'Jane\'s spec'`,
+		},
+		{
+			name:  "backslash",
+			build: func() *QueryBuilder { return NewQueryBuilder().Name(OperatorEquals, `a\b`) },
+			want:  `name = 'a\\b'`,
+		},
+		{
+			name:  "backslash before apostrophe",
+			build: func() *QueryBuilder { return NewQueryBuilder().FullTextContains(`\'`) },
+			want:  `fullText contains '\\\''`,
+		},
+		{
+			name: "conditions joined with and",
+			build: func() *QueryBuilder {
+				return NewQueryBuilder().NotTrashed().InParent("folder").ModifiedAfter(modified)
+			},
+			want: "trashed = false and 'folder' in parents and modifiedTime > '2024-03-01T11:00:00Z'",
+		},
+		{
+			name: "not single condition",
+			build: func() *QueryBuilder {
+				return NewQueryBuilder().Not(NewQueryBuilder().IsFolder())
+			},
+			want: "not mimeType = 'application/vnd.google-apps.folder'",
+		},
+		{
+			name: "not several conditions",
+			build: func() *QueryBuilder {
+				return NewQueryBuilder().Not(NewQueryBuilder().SharedWithMe().OwnedBy("a@b.com"))
+			},
+			want: "not (sharedWithMe = true and 'a@b.com' in owners)",
+		},
+		{
+			name: "not empty",
+			build: func() *QueryBuilder {
+				return NewQueryBuilder().NotTrashed().Not(NewQueryBuilder())
+			},
+			want: "trashed = false",
+		},
+		{
+			name: "nested groups",
+			build: func() *QueryBuilder {
+				return NewQueryBuilder().NotTrashed().Or(
+					NewQueryBuilder().IsFolder(),
+					NewQueryBuilder().MimeType(MimeTypeDocument).And(
+						NewQueryBuilder().Name(OperatorContains, "spec"),
+						NewQueryBuilder().Name(OperatorContains, "draft"),
+					),
+				)
+			},
+			want: "trashed = false and (mimeType = 'application/vnd.google-apps.folder' or " +
+				"(mimeType = 'application/vnd.google-apps.document' and (name contains 'spec' and name contains 'draft')))",
+		},
+		{
+			name: "empty groups are left out",
+			build: func() *QueryBuilder {
+				return NewQueryBuilder().NotTrashed().Or(NewQueryBuilder(), NewQueryBuilder())
+			},
+			want: "trashed = false",
+		},
+		{
+			name:  "properties has",
+			build: func() *QueryBuilder { return NewQueryBuilder().HasProperty("team", "O'Brien") },
+			want:  `properties has { key='team' and value='O\'Brien' }`,
+		},
+		{
+			name:  "appProperties has",
+			build: func() *QueryBuilder { return NewQueryBuilder().HasAppProperty("spec_id", "EN001") },
+			want:  "appProperties has { key='spec_id' and value='EN001' }",
+		},
+		{
+			name:    "property without key",
+			build:   func() *QueryBuilder { return NewQueryBuilder().HasAppProperty("", "EN001") },
+			wantErr: true,
+		},
+		{
+			name:    "unknown term",
+			build:   func() *QueryBuilder { return NewQueryBuilder().Where("title", OperatorEquals, "spec") },
+			wantErr: true,
+		},
+		{
+			name:    "unsupported operator",
+			build:   func() *QueryBuilder { return NewQueryBuilder().Where(FieldParents, OperatorEquals, "folder") },
+			wantErr: true,
+		},
+		{
+			name:    "contains on a bool",
+			build:   func() *QueryBuilder { return NewQueryBuilder().Where(FieldTrashed, OperatorContains, true) },
+			wantErr: true,
+		},
+		{
+			name:    "string for a time",
+			build:   func() *QueryBuilder { return NewQueryBuilder().Where(FieldModifiedTime, OperatorGreater, "2024-03-01") },
+			wantErr: true,
+		},
+		{
+			name:    "has on a string term",
+			build:   func() *QueryBuilder { return NewQueryBuilder().Where(FieldName, OperatorHas, "spec") },
+			wantErr: true,
+		},
+		{
+			name: "invalid condition in a group",
+			build: func() *QueryBuilder {
+				return NewQueryBuilder().NotTrashed().Or(
+					NewQueryBuilder().IsFolder(),
+					NewQueryBuilder().Where(FieldOwners, OperatorContains, "a@b.com"),
+				)
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid condition in a negation",
+			build: func() *QueryBuilder {
+				return NewQueryBuilder().Not(NewQueryBuilder().Where(FieldStarred, OperatorEquals, "yes"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.build().Build()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Build() = %q, want an error", got)
+				}
+				if got != "" {
+					t.Errorf("Build() = %q with an error, want an empty query", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Build() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Build() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
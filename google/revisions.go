@@ -0,0 +1,110 @@
+package google
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+)
+
+// RevisionResult represents a single revision of a file with potential error
+type RevisionResult struct {
+	Revision *drive.Revision
+	Err      error
+}
+
+// ListRevisionsChannel streams the revisions of a file through a channel, oldest first.
+// Drive merges the revisions of Google Docs and may drop old ones, so the list is not a
+// complete edit history.
+func (g *Google) ListRevisionsChannel(ctx context.Context, fileID string) <-chan RevisionResult {
+	resultChan := make(chan RevisionResult)
+
+	userFields := NewFieldBuilder().
+		SubFields(FieldLastModifyingUser, FieldDisplayName, FieldEmailAddress).
+		Build()
+	fields := NewFieldBuilder().
+		Pagination().
+		SubFields(FieldRevisions, FieldID, FieldModifiedTime, FieldExportLinks, userFields).
+		Build()
+
+	go func() {
+		defer close(resultChan)
+
+		pageToken := ""
+		for {
+			select {
+			case <-ctx.Done():
+				resultChan <- RevisionResult{Err: ctx.Err()}
+				return
+			default:
+			}
+
+			revisionList, err := g.DriveService.Revisions.List(fileID).
+				Context(ctx).
+				Fields(googleapi.Field(fields)).
+				PageToken(pageToken).
+				Do()
+			if err != nil {
+				resultChan <- RevisionResult{Err: err}
+				return
+			}
+
+			for _, revision := range revisionList.Revisions {
+				select {
+				case resultChan <- RevisionResult{Revision: revision}:
+				case <-ctx.Done():
+					resultChan <- RevisionResult{Err: ctx.Err()}
+					return
+				}
+			}
+
+			pageToken = revisionList.NextPageToken
+			if pageToken == "" {
+				return
+			}
+		}
+	}()
+
+	return resultChan
+}
+
+// ExportRevision exports a revision of a Google Doc through its export link. Revisions
+// without a link for the format, such as purged ones, cannot be exported.
+func (g *Google) ExportRevision(ctx context.Context, revision *drive.Revision, format string) (string, error) {
+	link, ok := revision.ExportLinks[format]
+	if !ok {
+		return "", fmt.Errorf("revision %s cannot be exported as %s", revision.Id, format)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := g.Client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if err := googleapi.CheckResponse(resp); err != nil {
+		return "", err
+	}
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
+
+// RevisionMetadataTable extracts the metadata table of a revision of a Google Doc, like
+// DocumentMetadataTable does for its current content
+func (g *Google) RevisionMetadataTable(ctx context.Context, revision *drive.Revision) ([][]string, error) {
+	content, err := g.ExportRevision(ctx, revision, MimeTypeHTML)
+	if err != nil {
+		return nil, err
+	}
+	return htmlMetadataTable(content)
+}
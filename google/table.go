@@ -0,0 +1,323 @@
+package google
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"google.golang.org/api/docs/v1"
+)
+
+// Table is a table read from the Docs API representation of a document. Unlike the
+// HTML export used by DocumentMetadataTable, it keeps person chips, links and the
+// indices needed to edit the table in place.
+type Table struct {
+	// Position is the index of the table among the top level tables of the document,
+	// counted across its tabs
+	Position int
+	// TabID is the tab holding the table, see DocumentTab
+	TabID      string
+	StartIndex int64
+	EndIndex   int64
+	Rows       []TableRow
+}
+
+// TableRow is a row of a Table
+type TableRow struct {
+	StartIndex int64
+	EndIndex   int64
+	Cells      []TableCell
+}
+
+// TableCell is a cell of a Table.
+//
+// StartIndex and EndIndex delimit the whole cell, while ContentStartIndex and
+// ContentEndIndex delimit its content without the trailing newline every cell ends
+// with, i.e. the range to delete when replacing the cell text. ContentStartIndex equals
+// ContentEndIndex for an empty cell.
+type TableCell struct {
+	// TabID is the tab holding the cell, to set on the locations of update requests
+	TabID string
+	// Text is the plain text of the cell, person chips are rendered as their name
+	Text   string
+	People []Person
+	Links  []Link
+
+	StartIndex        int64
+	EndIndex          int64
+	ContentStartIndex int64
+	ContentEndIndex   int64
+
+	// RowSpan and ColumnSpan are greater than 1 for merged cells
+	RowSpan    int64
+	ColumnSpan int64
+}
+
+// Person is a person chip, or a mailto link used as one in older documents
+type Person struct {
+	Name  string
+	Email string
+}
+
+// String formats the person as "Name <email>", the form ParsePerson reads back. People
+// without a name are formatted as their email and people without an email as their name.
+func (p Person) String() string {
+	switch {
+	case p.Email == "":
+		return p.Name
+	case p.Name == "":
+		return p.Email
+	}
+	return p.Name + " <" + p.Email + ">"
+}
+
+// ParsePerson reads a person formatted by Person.String. A bare email address is read
+// as the email of a person without a name, any other text as a name.
+func ParsePerson(value string) Person {
+	value = strings.TrimSpace(value)
+	if name, rest, ok := strings.Cut(value, "<"); ok {
+		if email, _, ok := strings.Cut(rest, ">"); ok {
+			return Person{Name: strings.TrimSpace(name), Email: strings.TrimSpace(email)}
+		}
+	}
+	if strings.Contains(value, "@") && !strings.ContainsAny(value, " \t") {
+		return Person{Email: value}
+	}
+	return Person{Name: value}
+}
+
+// Link is a rich link chip or a hyperlink on some text
+type Link struct {
+	Title    string
+	URL      string
+	MimeType string
+}
+
+// Value returns the cell as a string the same way DocumentMetadataTable does: the people
+// in the cell formatted by Person.String and separated by commas if there are any, the
+// text otherwise.
+func (c TableCell) Value() string {
+	if len(c.People) == 0 {
+		return c.Text
+	}
+	people := make([]string, 0, len(c.People))
+	for _, person := range c.People {
+		people = append(people, person.String())
+	}
+	return strings.Join(people, ",")
+}
+
+// Emails returns the email addresses of the people in the cell
+func (c TableCell) Emails() []string {
+	emails := []string{}
+	for _, person := range c.People {
+		if person.Email != "" {
+			emails = append(emails, person.Email)
+		}
+	}
+	return emails
+}
+
+// Strings converts the table into the 2D string array returned by DocumentMetadataTable.
+// Row and column positions match the Rows and Cells of the table.
+func (t *Table) Strings() [][]string {
+	result := make([][]string, 0, len(t.Rows))
+	for _, row := range t.Rows {
+		values := make([]string, 0, len(row.Cells))
+		for _, cell := range row.Cells {
+			values = append(values, cell.Value())
+		}
+		result = append(result, values)
+	}
+	return result
+}
+
+// Cell returns the cell at the given position, or an error if it is out of bounds
+func (t *Table) Cell(row, col int) (*TableCell, error) {
+	if row < 0 || row >= len(t.Rows) {
+		return nil, fmt.Errorf("row index %d out of bounds (max %d)", row, len(t.Rows)-1)
+	}
+	cells := t.Rows[row].Cells
+	if col < 0 || col >= len(cells) {
+		return nil, fmt.Errorf("column index %d out of bounds (max %d)", col, len(cells)-1)
+	}
+	return &cells[col], nil
+}
+
+// MetadataHeaders identify the metadata table of a spec among the tables of its Doc,
+// they are found in both template designs
+var MetadataHeaders = []string{"index", "title", "type", "status"}
+
+// minMetadataHeaders is the number of MetadataHeaders a table must have to be taken for
+// the metadata table
+const minMetadataHeaders = 3
+
+// MetadataTableScore returns the number of distinct MetadataHeaders among the cells of
+// a table
+func MetadataTableScore(values [][]string) int {
+	found := map[string]bool{}
+	for _, row := range values {
+		for _, cell := range row {
+			cell = strings.ToLower(strings.TrimSpace(cell))
+			if slices.Contains(MetadataHeaders, cell) {
+				found[cell] = true
+			}
+		}
+	}
+	return len(found)
+}
+
+// MetadataTableIndex returns the position of the metadata table among the tables of a
+// Doc: the first one with the most MetadataHeaders, or the first table when none has
+// enough of them, as in Docs predating the headers. It returns -1 without tables.
+func MetadataTableIndex(tables [][][]string) int {
+	if len(tables) == 0 {
+		return -1
+	}
+	index, bestScore := 0, minMetadataHeaders-1
+	for i, values := range tables {
+		if score := MetadataTableScore(values); score > bestScore {
+			index, bestScore = i, score
+		}
+	}
+	return index
+}
+
+// DocumentTable fetches a document from the Docs API and returns its metadata table,
+// found by MetadataTableIndex. Banners, callouts and tables of contents placed before
+// it are skipped the same way by the sync and the reject service.
+func (g *Google) DocumentTable(ctx context.Context, docID string) (*Table, error) {
+	doc, err := g.GetDocument(ctx, docID)
+	if err != nil {
+		return nil, err
+	}
+
+	table := MetadataTable(DocumentTables(doc))
+	if table == nil {
+		return nil, fmt.Errorf("no table found in the document")
+	}
+	if len(table.Rows) == 0 {
+		return nil, fmt.Errorf("table found but no data could be extracted")
+	}
+
+	return table, nil
+}
+
+// MetadataTable returns the metadata table among the tables of a document, or nil
+// without tables
+func MetadataTable(tables []*Table) *Table {
+	values := make([][][]string, len(tables))
+	for i, table := range tables {
+		values[i] = table.Strings()
+	}
+	index := MetadataTableIndex(values)
+	if index < 0 {
+		return nil
+	}
+	return tables[index]
+}
+
+// DocumentTables returns the top level tables in the tabs of a document, in order
+func DocumentTables(doc *docs.Document) []*Table {
+	var tables []*Table
+	for _, tab := range DocumentTabs(doc) {
+		for _, elem := range tab.Body.Content {
+			if elem.Table != nil {
+				table := newTable(elem, tab.TabID)
+				table.Position = len(tables)
+				tables = append(tables, table)
+			}
+		}
+	}
+	return tables
+}
+
+func newTable(elem *docs.StructuralElement, tabID string) *Table {
+	table := &Table{
+		TabID:      tabID,
+		StartIndex: elem.StartIndex,
+		EndIndex:   elem.EndIndex,
+	}
+	for _, row := range elem.Table.TableRows {
+		tableRow := TableRow{
+			StartIndex: row.StartIndex,
+			EndIndex:   row.EndIndex,
+		}
+		for _, cell := range row.TableCells {
+			tableRow.Cells = append(tableRow.Cells, newTableCell(cell, tabID))
+		}
+		table.Rows = append(table.Rows, tableRow)
+	}
+	return table
+}
+
+func newTableCell(cell *docs.TableCell, tabID string) TableCell {
+	result := TableCell{
+		TabID:      tabID,
+		StartIndex: cell.StartIndex,
+		EndIndex:   cell.EndIndex,
+		RowSpan:    1,
+		ColumnSpan: 1,
+	}
+	if style := cell.TableCellStyle; style != nil {
+		if style.RowSpan > 0 {
+			result.RowSpan = style.RowSpan
+		}
+		if style.ColumnSpan > 0 {
+			result.ColumnSpan = style.ColumnSpan
+		}
+	}
+
+	var text strings.Builder
+	first := true
+	for _, content := range cell.Content {
+		if content.Paragraph == nil {
+			continue
+		}
+		for _, elem := range content.Paragraph.Elements {
+			if first {
+				result.ContentStartIndex = elem.StartIndex
+				first = false
+			}
+			result.ContentEndIndex = elem.EndIndex
+
+			switch {
+			case elem.Person != nil && elem.Person.PersonProperties != nil:
+				props := elem.Person.PersonProperties
+				result.People = append(result.People, Person{Name: props.Name, Email: props.Email})
+				text.WriteString(props.Name)
+			case elem.RichLink != nil && elem.RichLink.RichLinkProperties != nil:
+				props := elem.RichLink.RichLinkProperties
+				result.Links = append(result.Links, Link{Title: props.Title, URL: props.Uri, MimeType: props.MimeType})
+				text.WriteString(props.Title)
+			case elem.TextRun != nil:
+				text.WriteString(elem.TextRun.Content)
+				addTextRunLink(&result, elem.TextRun)
+			}
+		}
+	}
+
+	// every cell ends with a newline which cannot be deleted
+	if result.ContentEndIndex > result.ContentStartIndex {
+		result.ContentEndIndex--
+	}
+	result.Text = strings.TrimSpace(text.String())
+
+	return result
+}
+
+// addTextRunLink records the hyperlink of a text run, mailto links being treated as people
+func addTextRunLink(cell *TableCell, run *docs.TextRun) {
+	if run.TextStyle == nil || run.TextStyle.Link == nil || run.TextStyle.Link.Url == "" {
+		return
+	}
+
+	url := run.TextStyle.Link.Url
+	name := strings.TrimSpace(run.Content)
+	if email, ok := strings.CutPrefix(url, "mailto:"); ok {
+		cell.People = append(cell.People, Person{Name: name, Email: email})
+		return
+	}
+	cell.Links = append(cell.Links, Link{Title: name, URL: url})
+}
@@ -0,0 +1,56 @@
+package google
+
+import "google.golang.org/api/docs/v1"
+
+// DocumentTab is the content of a tab of a document
+type DocumentTab struct {
+	// TabID addresses the tab in update requests, it is empty for a document read
+	// without its tabs content
+	TabID string
+	Title string
+	Body  *docs.Body
+}
+
+// DocumentTabs returns the tabs of a document in the order they are shown, child tabs
+// following their parent. A document read without its tabs content has a single tab
+// holding its body.
+func DocumentTabs(doc *docs.Document) []DocumentTab {
+	if doc == nil {
+		return nil
+	}
+	if len(doc.Tabs) == 0 {
+		if doc.Body == nil {
+			return nil
+		}
+		return []DocumentTab{{Body: doc.Body}}
+	}
+
+	var tabs []DocumentTab
+	var walk func(children []*docs.Tab)
+	walk = func(children []*docs.Tab) {
+		for _, tab := range children {
+			if tab.DocumentTab != nil && tab.DocumentTab.Body != nil {
+				documentTab := DocumentTab{Body: tab.DocumentTab.Body}
+				if tab.TabProperties != nil {
+					documentTab.TabID = tab.TabProperties.TabId
+					documentTab.Title = tab.TabProperties.Title
+				}
+				tabs = append(tabs, documentTab)
+			}
+			walk(tab.ChildTabs)
+		}
+	}
+	walk(doc.Tabs)
+	return tabs
+}
+
+// DocumentTabByID returns the tab of a document with the given ID, the body of a
+// document read without its tabs content having an empty ID
+func DocumentTabByID(doc *docs.Document, tabID string) (DocumentTab, bool) {
+	for _, tab := range DocumentTabs(doc) {
+		if tab.TabID == tabID {
+			return tab, true
+		}
+	}
+	return DocumentTab{}, false
+}
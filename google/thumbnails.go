@@ -0,0 +1,32 @@
+package google
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	"google.golang.org/api/googleapi"
+)
+
+// maxThumbnailSize bounds the thumbnails read into memory, Drive serves them at most
+// a few hundred pixels wide
+const maxThumbnailSize = 5 << 20
+
+// DownloadThumbnail fetches the image behind the thumbnailLink of a file. The link is
+// short-lived and needs the credentials of a user allowed to read the file.
+func (g *Google) DownloadThumbnail(ctx context.Context, link string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := g.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if err := googleapi.CheckResponse(resp); err != nil {
+		return nil, err
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxThumbnailSize))
+}
@@ -0,0 +1,359 @@
+package google
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RetryConfig controls how failed Drive and Docs requests are retried
+type RetryConfig struct {
+	// MaxRetries is the number of retries after the first attempt, 0 disables retries
+	MaxRetries int
+	// InitialBackoff is the upper bound of the first backoff, doubled on every retry
+	InitialBackoff time.Duration
+	// MaxBackoff caps the backoff and any Retry-After delay
+	MaxBackoff time.Duration
+}
+
+// RateLimits are the sustained requests per second allowed for each API quota.
+// Zero means unlimited.
+type RateLimits struct {
+	DriveList   float64
+	DriveExport float64
+	DocsWrite   float64
+}
+
+// DefaultRetryConfig returns the retry settings used when none are configured
+func DefaultRetryConfig() RetryConfig {
+	return RetryConfig{
+		MaxRetries:     5,
+		InitialBackoff: 500 * time.Millisecond,
+		MaxBackoff:     32 * time.Second,
+	}
+}
+
+// Quota buckets requests are rate limited by
+const (
+	quotaDriveList   = "drive_list"
+	quotaDriveExport = "drive_export"
+	quotaDocsWrite   = "docs_write"
+)
+
+// retryableReasons are the Google API error reasons worth retrying on a 403, the rate
+// limit ones being sent before the request is applied
+var retryableReasons = map[string]bool{
+	"rateLimitExceeded":     true,
+	"userRateLimitExceeded": true,
+	"backendError":          false,
+}
+
+// retryPolicy tells which failures of a request may be retried
+type retryPolicy int
+
+const (
+	// retryNever is for requests that may have been applied whatever the failure
+	retryNever retryPolicy = iota
+	// retryRateLimited is for requests that must not be applied twice, they are only
+	// retried when rejected by a rate limit, before being applied
+	retryRateLimited
+	// retryTransient is for idempotent requests, retried on any transient failure
+	retryTransient
+)
+
+// retryPolicyFor returns the retry policy of a request. Reads and file metadata updates,
+// which set the same values again, are idempotent. Docs batch updates insert text and
+// rows, so a retry after a lost response would apply them twice.
+func retryPolicyFor(req *http.Request) retryPolicy {
+	path := req.URL.Path
+	switch {
+	case req.Method == http.MethodGet || req.Method == http.MethodHead:
+		return retryTransient
+	// files.update, used for app properties
+	case req.Method == http.MethodPatch && strings.Contains(path, "/files/"):
+		return retryTransient
+	// Drive Activity queries are reads sent as POST
+	case req.Method == http.MethodPost && strings.HasSuffix(path, "/activity:query"):
+		return retryTransient
+	case req.Method == http.MethodPost && strings.HasSuffix(path, ":batchUpdate"):
+		return retryRateLimited
+	}
+	return retryNever
+}
+
+// transport retries Drive and Docs requests failing with retryable errors using
+// exponential backoff with full jitter, and rate limits them per API quota
+type transport struct {
+	base     http.RoundTripper
+	logger   *slog.Logger
+	retry    RetryConfig
+	limiters map[string]*rateLimiter
+}
+
+func newTransport(base http.RoundTripper, logger *slog.Logger, retry RetryConfig, limits RateLimits) *transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	limiters := map[string]*rateLimiter{}
+	for quota, rate := range map[string]float64{
+		quotaDriveList:   limits.DriveList,
+		quotaDriveExport: limits.DriveExport,
+		quotaDocsWrite:   limits.DocsWrite,
+	} {
+		if rate > 0 {
+			limiters[quota] = newRateLimiter(rate, max(1, int(rate)))
+		}
+	}
+
+	return &transport{
+		base:     base,
+		logger:   logger.With("component", "google_transport"),
+		retry:    retry,
+		limiters: limiters,
+	}
+}
+
+// quotaFor returns the quota bucket of a request, or an empty string if it is not limited
+func quotaFor(req *http.Request) string {
+	path := req.URL.Path
+	switch {
+	// revision export links end with "/Export"
+	case strings.HasSuffix(strings.ToLower(path), "/export"):
+		return quotaDriveExport
+	case req.Method == http.MethodGet && (strings.HasSuffix(path, "/files") ||
+		strings.HasSuffix(path, "/changes") || strings.HasSuffix(path, "/revisions") ||
+		strings.HasSuffix(path, "/comments")):
+		return quotaDriveList
+	case req.Method == http.MethodPost && strings.HasSuffix(path, ":batchUpdate"):
+		return quotaDocsWrite
+	}
+	return ""
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	quota := quotaFor(req)
+	policy := retryPolicyFor(req)
+	logger := t.logger.With("method", req.Method, "path", req.URL.Path)
+
+	for attempt := 0; ; attempt++ {
+		if limiter, ok := t.limiters[quota]; ok {
+			waited, err := limiter.Wait(ctx)
+			if err != nil {
+				return nil, err
+			}
+			if waited > time.Second {
+				logger.Info("throttled google api request", "quota", quota, "waited", waited.String())
+			}
+		}
+
+		attemptReq := req
+		if attempt > 0 {
+			var err error
+			if attemptReq, err = rewindRequest(req); err != nil {
+				return nil, err
+			}
+		}
+
+		resp, err := t.base.RoundTrip(attemptReq)
+		if attempt >= t.retry.MaxRetries || !t.shouldRetry(policy, resp, err) || !canRewind(req) {
+			if attempt > 0 && err == nil && resp.StatusCode < 400 {
+				logger.Info("google api request succeeded after retries", "retries", attempt)
+			}
+			return resp, err
+		}
+
+		delay := t.backoff(attempt)
+		if resp != nil {
+			if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+				delay = min(retryAfter, t.retry.MaxBackoff)
+			}
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		attrs := []any{"attempt", attempt + 1, "max_retries", t.retry.MaxRetries, "delay", delay.String()}
+		if err != nil {
+			attrs = append(attrs, "error", err.Error())
+		} else {
+			attrs = append(attrs, "status", resp.StatusCode)
+		}
+		logger.Warn("retrying google api request", attrs...)
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// shouldRetry reports whether a request failed with an error its retry policy allows
+// retrying. The body of 403 responses is inspected for rate limit reasons and then
+// restored.
+func (t *transport) shouldRetry(policy retryPolicy, resp *http.Response, err error) bool {
+	if policy == retryNever {
+		return false
+	}
+	if err != nil {
+		// Cancellation is final, other transport errors are usually transient but the
+		// request may have been applied
+		return policy == retryTransient && !isContextError(err)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return policy == retryTransient
+	case http.StatusForbidden:
+		body, readErr := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		if readErr != nil {
+			return false
+		}
+		return hasRetryableReason(body, policy == retryTransient)
+	}
+	return false
+}
+
+// backoff returns a random delay between 0 and InitialBackoff * 2^attempt, capped at MaxBackoff
+func (t *transport) backoff(attempt int) time.Duration {
+	ceiling := t.retry.InitialBackoff << attempt
+	if ceiling <= 0 || ceiling > t.retry.MaxBackoff {
+		ceiling = t.retry.MaxBackoff
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return rand.N(ceiling)
+}
+
+// hasRetryableReason reports whether a 403 body gives a rate limit reason, or any
+// retryable reason when transient errors are retried
+func hasRetryableReason(body []byte, transient bool) bool {
+	var apiErr struct {
+		Error struct {
+			Errors []struct {
+				Reason string `json:"reason"`
+			} `json:"errors"`
+			Status string `json:"status"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &apiErr); err != nil {
+		return false
+	}
+	if apiErr.Error.Status == "RESOURCE_EXHAUSTED" {
+		return true
+	}
+	for _, e := range apiErr.Error.Errors {
+		if rateLimited, ok := retryableReasons[e.Reason]; ok && (rateLimited || transient) {
+			return true
+		}
+	}
+	return false
+}
+
+func isContextError(err error) bool {
+	return err == context.Canceled || err == context.DeadlineExceeded ||
+		strings.Contains(err.Error(), context.Canceled.Error()) ||
+		strings.Contains(err.Error(), context.DeadlineExceeded.Error())
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an HTTP date
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(0, time.Until(date)), true
+	}
+	return 0, false
+}
+
+// canRewind reports whether the request body can be sent again
+func canRewind(req *http.Request) bool {
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+func rewindRequest(req *http.Request) (*http.Request, error) {
+	clone := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, fmt.Errorf("failed to rewind request body: %w", err)
+		}
+		clone.Body = body
+	}
+	return clone, nil
+}
+
+// rateLimiter is a token bucket refilled at a constant rate
+type rateLimiter struct {
+	mu       sync.Mutex
+	rate     float64
+	burst    float64
+	tokens   float64
+	lastFill time.Time
+}
+
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	return &rateLimiter{
+		rate:     rate,
+		burst:    float64(burst),
+		tokens:   float64(burst),
+		lastFill: time.Now(),
+	}
+}
+
+// Wait blocks until a token is available and returns how long it waited
+func (l *rateLimiter) Wait(ctx context.Context) (time.Duration, error) {
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens = min(l.burst, l.tokens+now.Sub(l.lastFill).Seconds()*l.rate)
+	l.lastFill = now
+
+	// Reserve the token now, callers queue up behind each other
+	l.tokens--
+	var delay time.Duration
+	if l.tokens < 0 {
+		delay = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	l.mu.Unlock()
+
+	if delay == 0 {
+		return 0, nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return delay, nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return 0, ctx.Err()
+	}
+}
@@ -0,0 +1,73 @@
+package google
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/api/drive/v3"
+)
+
+// WebhookChannelType is the delivery method of Drive push notifications
+const WebhookChannelType = "web_hook"
+
+// Push notification headers sent by Drive to a watch channel address
+const (
+	HeaderChannelID     = "X-Goog-Channel-ID"
+	HeaderChannelToken  = "X-Goog-Channel-Token"
+	HeaderResourceID    = "X-Goog-Resource-ID"
+	HeaderResourceState = "X-Goog-Resource-State"
+)
+
+// Resource states reported in the X-Goog-Resource-State header
+const (
+	ResourceStateSync   = "sync"
+	ResourceStateUpdate = "update"
+	ResourceStateTrash  = "trash"
+	ResourceStateRemove = "remove"
+	ResourceStateChange = "change"
+)
+
+// WatchRequest describes a notification channel to open
+type WatchRequest struct {
+	ChannelID  string
+	Address    string
+	Token      string
+	Expiration time.Time
+}
+
+func (w WatchRequest) channel() *drive.Channel {
+	return &drive.Channel{
+		Id:         w.ChannelID,
+		Type:       WebhookChannelType,
+		Address:    w.Address,
+		Token:      w.Token,
+		Expiration: w.Expiration.UnixMilli(),
+	}
+}
+
+// WatchFile opens a channel receiving notifications when the given file changes
+func (g *Google) WatchFile(ctx context.Context, fileID string, req WatchRequest) (*drive.Channel, error) {
+	return g.DriveService.Files.Watch(fileID, req.channel()).
+		Context(ctx).
+		SupportsAllDrives(true).
+		Do()
+}
+
+// WatchChanges opens a channel receiving notifications when the changes feed moves past
+// the given page token
+func (g *Google) WatchChanges(ctx context.Context, pageToken string, req WatchRequest) (*drive.Channel, error) {
+	return g.DriveService.Changes.Watch(pageToken, req.channel()).
+		Context(ctx).
+		IncludeRemoved(true).
+		SupportsAllDrives(true).
+		IncludeItemsFromAllDrives(true).
+		Do()
+}
+
+// StopChannel closes a notification channel
+func (g *Google) StopChannel(ctx context.Context, channelID, resourceID string) error {
+	return g.DriveService.Channels.Stop(&drive.Channel{
+		Id:         channelID,
+		ResourceId: resourceID,
+	}).Context(ctx).Do()
+}
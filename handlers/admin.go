@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/canonical/specs-v2.canonical.com/specs"
+	"github.com/labstack/echo/v4"
+)
+
+// SharingFinding is a spec shared outside the company or its owning team
+type SharingFinding struct {
+	SpecID       string   `json:"spec_id"`
+	Title        string   `json:"title"`
+	Team         string   `json:"team"`
+	URL          string   `json:"url"`
+	SharingLevel string   `json:"sharing_level"`
+	External     []string `json:"external"`
+	OutsideTeam  []string `json:"outside_team"`
+}
+
+type SharingReportResponse struct {
+	Findings []SharingFinding `json:"findings"`
+}
+
+// SharingReport lists the specs shared outside the company domains or their owning team
+func (s *Server) SharingReport(c echo.Context) error {
+	var members specs.TeamMembers
+	if s.Config.AuditTeamMembersFile != "" {
+		var err error
+		members, err = specs.LoadTeamMembers(s.Config.AuditTeamMembersFile)
+		if err != nil {
+			s.Logger.Error("failed to load team members", "error", err.Error())
+			return echo.NewHTTPError(http.StatusInternalServerError, "Failed to load team members")
+		}
+	}
+
+	findings, err := specs.SharingReport(s.DB, members)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to build sharing report")
+	}
+
+	response := SharingReportResponse{Findings: make([]SharingFinding, len(findings))}
+	for i, finding := range findings {
+		response.Findings[i] = SharingFinding{
+			SpecID:       finding.SpecID,
+			Title:        finding.Title,
+			Team:         finding.Team,
+			URL:          finding.URL,
+			SharingLevel: finding.SharingLevel,
+			External:     finding.External,
+			OutsideTeam:  finding.OutsideTeam,
+		}
+		if response.Findings[i].External == nil {
+			response.Findings[i].External = []string{}
+		}
+		if response.Findings[i].OutsideTeam == nil {
+			response.Findings[i].OutsideTeam = []string{}
+		}
+	}
+	return c.JSON(http.StatusOK, response)
+}
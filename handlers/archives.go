@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/canonical/specs-v2.canonical.com/db"
+	"github.com/canonical/specs-v2.canonical.com/specs"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// SpecArchive is a PDF snapshot of a spec taken when it reached a terminal status
+type SpecArchive struct {
+	ID                 string    `json:"id"`
+	Status             string    `json:"status"`
+	Size               int64     `json:"size"`
+	GoogleDocUpdatedAt time.Time `json:"google_doc_updated_at"`
+	ArchivedAt         time.Time `json:"archived_at"`
+}
+
+type SpecArchivesResponse struct {
+	SpecID   string        `json:"spec_id"`
+	Archives []SpecArchive `json:"archives"`
+}
+
+// archiveQuery returns a query on the archives of a spec visible to the signed-in user:
+// those of specs they can open, and those they could read when they were taken. Archives
+// outlive their spec, so they are found even once the spec left Drive.
+func (s *Server) archiveQuery(c echo.Context) (*gorm.DB, error) {
+	if s.Archive == nil {
+		return nil, echo.NewHTTPError(http.StatusNotFound, "Spec archiving is not enabled")
+	}
+	principal, err := s.principal(c)
+	if err != nil {
+		return nil, err
+	}
+
+	query := s.DB.Model(&db.SpecArchive{}).Where("spec_id = ?", c.Param("id"))
+	if principal != nil {
+		query = query.Where("spec_id IN (?) OR id IN (?)",
+			specs.VisibleSpecIDs(s.DB, *principal), specs.VisibleArchiveIDs(s.DB, *principal))
+	}
+	return query, nil
+}
+
+// SpecArchives lists the PDF snapshots of a spec, newest first
+func (s *Server) SpecArchives(c echo.Context) error {
+	query, err := s.archiveQuery(c)
+	if err != nil {
+		return err
+	}
+
+	var archives []db.SpecArchive
+	if err := query.Order("archived_at DESC").Find(&archives).Error; err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to fetch spec archives")
+	}
+
+	response := SpecArchivesResponse{
+		SpecID:   c.Param("id"),
+		Archives: make([]SpecArchive, len(archives)),
+	}
+	for i, archive := range archives {
+		response.Archives[i] = SpecArchive{
+			ID:                 archive.ID,
+			Status:             archive.Status,
+			Size:               archive.Size,
+			GoogleDocUpdatedAt: archive.GoogleDocUpdatedAt,
+			ArchivedAt:         archive.ArchivedAt,
+		}
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+// DownloadSpecArchive streams a PDF snapshot of a spec from the archive bucket
+func (s *Server) DownloadSpecArchive(c echo.Context) error {
+	query, err := s.archiveQuery(c)
+	if err != nil {
+		return err
+	}
+
+	var archive db.SpecArchive
+	err = query.Where("id = ?", c.Param("archiveId")).First(&archive).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return echo.NewHTTPError(http.StatusNotFound, "Archive not found")
+	}
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to fetch spec archive")
+	}
+
+	body, err := s.Archive.GetObject(c.Request().Context(), archive.ObjectKey)
+	if err != nil {
+		s.Logger.Error("failed to get archive", "object_key", archive.ObjectKey, "error", err.Error())
+		return echo.NewHTTPError(http.StatusBadGateway, "Failed to download spec archive")
+	}
+	defer body.Close()
+
+	filename := fmt.Sprintf("%s-%s", archive.SpecID, path.Base(archive.ObjectKey))
+	c.Response().Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	return c.Stream(http.StatusOK, "application/pdf", body)
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+
+	"github.com/canonical/specs-v2.canonical.com/db"
+	"github.com/canonical/specs-v2.canonical.com/google"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// DriveNotification receives Drive push notifications for the watch channels opened by
+// the sync service, and queues the affected Doc for re-parsing. Notifications from
+// changes channels queue a pass over the changes feed instead.
+func (s *Server) DriveNotification(c echo.Context) error {
+	if s.Config.DriveWebhookToken == "" {
+		return echo.NewHTTPError(http.StatusNotFound)
+	}
+
+	headers := c.Request().Header
+	token := headers.Get(google.HeaderChannelToken)
+	if subtle.ConstantTimeCompare([]byte(token), []byte(s.Config.DriveWebhookToken)) != 1 {
+		return echo.NewHTTPError(http.StatusForbidden, "Invalid channel token")
+	}
+
+	channelID := headers.Get(google.HeaderChannelID)
+	state := headers.Get(google.HeaderResourceState)
+	logger := s.Logger.With("channel_id", channelID, "resource_state", state)
+
+	var channel db.WatchChannel
+	if err := s.DB.Where("id = ?", channelID).First(&channel).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Unknown channels are acknowledged so Drive stops retrying, they expire on their own
+			logger.Warn("notification for unknown channel")
+			return c.NoContent(http.StatusOK)
+		}
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to fetch channel")
+	}
+
+	// The first message on a channel only confirms it was opened
+	if state == google.ResourceStateSync {
+		return c.NoContent(http.StatusOK)
+	}
+
+	item := db.SyncQueueItem{
+		ID:            uuid.NewString(),
+		ResourceState: state,
+	}
+	if channel.Kind == db.WatchChannelKindFile {
+		item.GoogleDocID = channel.GoogleDocID
+	}
+	if err := s.DB.Create(&item).Error; err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to queue notification")
+	}
+
+	logger.Debug("queued document for sync", "google_doc_id", item.GoogleDocID)
+	return c.NoContent(http.StatusOK)
+}
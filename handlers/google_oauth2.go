@@ -7,6 +7,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
@@ -52,6 +53,17 @@ func (s *Server) AuthMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 	}
 }
 
+// AdminMiddleware restricts a route to the configured admins, it must run after AuthMiddleware
+func (s *Server) AdminMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		email, _ := c.Get("email").(string)
+		if !s.Config.IsAdmin(email) {
+			return echo.NewHTTPError(http.StatusForbidden, "Admin access required")
+		}
+		return next(c)
+	}
+}
+
 func (s *Server) HandleGoogleLogin(c echo.Context) error {
 	oauth := s.initGoogleOAuth()
 	url := oauth.AuthCodeURL("state")
@@ -92,7 +104,7 @@ func (s *Server) HandleGoogleCallback(c echo.Context) error {
 
 func createSession(config *config.Config, email string) string {
 	timestamp := time.Now().Unix()
-	payload := email + ":" + string(timestamp)
+	payload := email + ":" + strconv.FormatInt(timestamp, 10)
 
 	h := hmac.New(sha256.New, []byte(config.AppSecretKey))
 	h.Write([]byte(payload))
@@ -6,6 +6,7 @@ import (
 	"net/http"
 
 	"github.com/canonical/specs-v2.canonical.com/config"
+	"github.com/canonical/specs-v2.canonical.com/specs"
 	"github.com/canonical/specs-v2.canonical.com/ui"
 	"github.com/go-playground/validator"
 	"github.com/labstack/echo/v4"
@@ -17,6 +18,12 @@ type Server struct {
 	Config *config.Config
 	DB     *gorm.DB
 	Echo   *echo.Echo
+	// Groups resolves the groups of signed-in users when visibility is enforced
+	Groups specs.GroupResolver
+	// Archive serves the PDF snapshots of specs, nil when archiving is not enabled
+	Archive specs.ObjectStore
+	// Thumbnails serves the cached Drive thumbnails of specs, nil when disabled
+	Thumbnails specs.ObjectStore
 }
 
 type CustomValidator struct {
@@ -60,10 +67,23 @@ func NewServer(logger *slog.Logger, config *config.Config, db *gorm.DB) *Server
 	e.GET("/auth/google/login", server.HandleGoogleLogin)
 	e.GET("/auth/google/callback", server.HandleGoogleCallback)
 
+	// Drive push notifications are authenticated with the channel token
+	e.POST("/api/drive/notifications", server.DriveNotification)
+
 	e.GET("/api/specs", server.ListSpecs, server.AuthMiddleware)
 	e.GET("/api/specs/authors", server.SpecAuthors, server.AuthMiddleware)
 	e.GET("/api/specs/reviewers", server.SpecReviewers, server.AuthMiddleware)
 	e.GET("/api/specs/teams", server.SpecTeams, server.AuthMiddleware)
+	e.GET("/api/people/authors", server.AuthorPeople, server.AuthMiddleware)
+	e.GET("/api/people/reviewers", server.ReviewerPeople, server.AuthMiddleware)
+	e.GET("/api/specs/:id/history", server.SpecHistory, server.AuthMiddleware)
+	e.GET("/api/specs/:id/status-changes", server.SpecStatusChanges, server.AuthMiddleware)
+	e.GET("/api/specs/:id/conflicts", server.SpecPropertyConflicts, server.AuthMiddleware)
+	e.GET("/api/specs/:id/thumbnail", server.SpecThumbnail, server.AuthMiddleware)
+	e.GET("/api/specs/:id/archives", server.SpecArchives, server.AuthMiddleware)
+	e.GET("/api/specs/:id/archives/:archiveId", server.DownloadSpecArchive, server.AuthMiddleware)
+
+	e.GET("/api/admin/sharing", server.SharingReport, server.AuthMiddleware, server.AdminMiddleware)
 
 	// Serve static files from dist directory
 	fsys, _ := fs.Sub(ui.UIAssets, "dist")
@@ -8,12 +8,13 @@ import (
 
 	"github.com/canonical/specs-v2.canonical.com/db"
 	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
 )
 
 type ListSpecsRequest struct {
 	Limit       int32    `query:"limit" validate:"min=1,max=100"`
 	Offset      int32    `query:"offset" validate:"min=0"`
-	OrderBy     string   `query:"orderBy" validate:"oneof=created_at updated_at title team id"`
+	OrderBy     string   `query:"orderBy" validate:"oneof=created_at updated_at declared_created_at last_reviewed_at title team id"`
 	OrderDir    string   `query:"orderDir" validate:"oneof=asc desc"`
 	Title       string   `query:"title"`
 	Team        string   `query:"team"`
@@ -22,6 +23,10 @@ type ListSpecsRequest struct {
 	Author      string   `query:"author"`
 	Reviewer    string   `query:"reviewer"`
 	SearchQuery string   `query:"searchQuery"`
+	// UnresolvedComments keeps the specs with open comment threads
+	UnresolvedComments bool `query:"unresolvedComments"`
+	// Commenter keeps the specs commented on by a name or email
+	Commenter string `query:"commenter"`
 }
 
 type Spec struct {
@@ -36,9 +41,38 @@ type Spec struct {
 	GoogleDocURL       string    `json:"google_doc_url"`
 	GoogleDocCreatedAt time.Time `json:"google_doc_created_at"`
 	GoogleDocUpdatedAt time.Time `json:"google_doc_updated_at"`
+	SourceFormat       string    `json:"source_format"`
+	TemplateVersion    string    `json:"template_version"`
+	SharingLevel       string    `json:"sharing_level"`
 	CreatedAt          time.Time `json:"created_at"`
 	UpdatedAt          time.Time `json:"updated_at"`
 	SyncedAt           time.Time `json:"synced_at"`
+	// ThumbnailURL serves the Drive thumbnail of the spec, empty without one
+	ThumbnailURL string `json:"thumbnail_url"`
+	// Comment activity on the spec's file, LastCommentAt is null without comments
+	OpenComments     int        `json:"open_comments"`
+	ResolvedComments int        `json:"resolved_comments"`
+	LastCommentAt    *time.Time `json:"last_comment_at"`
+	Commenters       []string   `json:"commenters"`
+	// DeclaredCreatedAt is the creation date written in the metadata table, often earlier
+	// than GoogleDocCreatedAt for Docs copied from a template. It is null when missing.
+	DeclaredCreatedAt *time.Time `json:"declared_created_at"`
+	Reviewers         []Reviewer `json:"reviewers"`
+}
+
+// Reviewer is a reviewer of a spec, ReviewedAt is null when the table gives no date
+type Reviewer struct {
+	Name       string     `json:"name"`
+	Email      string     `json:"email"`
+	Status     string     `json:"status"`
+	ReviewedAt *time.Time `json:"reviewed_at"`
+}
+
+// Person is an author or reviewer, Email is empty for the people known by name only
+type Person struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
 }
 
 type ListSpecsResponse struct {
@@ -48,6 +82,54 @@ type ListSpecsResponse struct {
 	Offset int32  `json:"offset"`
 }
 
+// SpecStatusPeriod is a period during which a spec had a status. EndedAt is null for
+// the current status.
+type SpecStatusPeriod struct {
+	Status     string     `json:"status"`
+	StartedAt  time.Time  `json:"started_at"`
+	EndedAt    *time.Time `json:"ended_at"`
+	RevisionID string     `json:"revision_id"`
+	ChangedBy  string     `json:"changed_by"`
+}
+
+type SpecHistoryResponse struct {
+	SpecID  string             `json:"spec_id"`
+	History []SpecStatusPeriod `json:"history"`
+}
+
+// SpecStatusChange is a status change of a spec and its likely author. Actor is empty
+// when the author is unknown, and Automated is set for changes made by the reject job or
+// another automation account.
+type SpecStatusChange struct {
+	FromStatus string    `json:"from_status"`
+	ToStatus   string    `json:"to_status"`
+	ChangedAt  time.Time `json:"changed_at"`
+	Actor      string    `json:"actor"`
+	ActorName  string    `json:"actor_name"`
+	Automated  bool      `json:"automated"`
+}
+
+type SpecStatusChangesResponse struct {
+	SpecID  string             `json:"spec_id"`
+	Changes []SpecStatusChange `json:"changes"`
+}
+
+// SpecPropertyConflict is a value the metadata table of a spec disagrees with the app
+// properties of its file on. The table wins, so Property is the value it replaced, last
+// written by LastAction when an automation set it.
+type SpecPropertyConflict struct {
+	Key        string    `json:"key"`
+	Table      string    `json:"table"`
+	Property   string    `json:"property"`
+	LastAction string    `json:"last_action"`
+	DetectedAt time.Time `json:"detected_at"`
+}
+
+type SpecPropertyConflictsResponse struct {
+	SpecID    string                 `json:"spec_id"`
+	Conflicts []SpecPropertyConflict `json:"conflicts"`
+}
+
 func (r *ListSpecsRequest) setDefaults() {
 	if r.Limit == 0 {
 		r.Limit = 10
@@ -56,7 +138,8 @@ func (r *ListSpecsRequest) setDefaults() {
 		r.OrderBy = "created_at"
 	}
 	if r.OrderDir == "" {
-		if r.OrderBy == "updated_at" || r.OrderBy == "created_at" {
+		if r.OrderBy == "updated_at" || r.OrderBy == "created_at" ||
+			r.OrderBy == "declared_created_at" || r.OrderBy == "last_reviewed_at" {
 			r.OrderDir = "desc"
 		} else {
 			r.OrderDir = "asc"
@@ -78,8 +161,16 @@ func (s *Server) ListSpecs(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
 	}
 
+	visible, err := s.visibleSpecIDs(c)
+	if err != nil {
+		return err
+	}
+
 	var specs []db.Spec
 	query := s.DB.Model(&db.Spec{})
+	if visible != nil {
+		query = query.Where("specs.id IN (?)", visible)
+	}
 
 	if req.Title != "" {
 		query = query.Where("title ILIKE ?", "%"+req.Title+"%")
@@ -99,13 +190,36 @@ func (s *Server) ListSpecs(c echo.Context) error {
 		query = query.Where("lower(status) IN ?", lowercaseStatus)
 	}
 
+	// authors and reviewers are matched by the person IDs listed by AuthorPeople and
+	// ReviewerPeople, or by a part of their name or email as before people were stored
 	if req.Author != "" {
-		query = query.Where("ARRAY_TO_STRING(authors, ' ') ILIKE ?", "%"+strings.TrimSpace(req.Author)+"%")
+		author := strings.TrimSpace(req.Author)
+		query = query.Where(
+			"EXISTS (SELECT 1 FROM spec_authors sa JOIN people p ON p.id = sa.person_id WHERE sa.spec_id = specs.id AND "+
+				"(sa.person_id = ? OR p.name ILIKE ? OR p.email ILIKE ?))",
+			strings.ToLower(author), "%"+author+"%", "%"+author+"%",
+		)
 	}
 
 	if req.Reviewer != "" {
-		query = query.Joins("JOIN reviewers rev ON rev.spec_id = specs.id").
-			Where("rev.name ILIKE ?", "%"+strings.TrimSpace(req.Reviewer)+"%")
+		reviewer := strings.TrimSpace(req.Reviewer)
+		query = query.Where(
+			"EXISTS (SELECT 1 FROM spec_reviewers sr JOIN people p ON p.id = sr.person_id WHERE sr.spec_id = specs.id AND "+
+				"(sr.person_id = ? OR p.name ILIKE ? OR p.email ILIKE ?))",
+			strings.ToLower(reviewer), "%"+reviewer+"%", "%"+reviewer+"%",
+		)
+	}
+
+	if req.UnresolvedComments {
+		query = query.Where("EXISTS (SELECT 1 FROM spec_comment_activity ca WHERE ca.spec_id = specs.id AND ca.open_comments > 0)")
+	}
+
+	if req.Commenter != "" {
+		commenter := "%" + strings.TrimSpace(req.Commenter) + "%"
+		query = query.Where(
+			"EXISTS (SELECT 1 FROM spec_commenters sc WHERE sc.spec_id = specs.id AND (sc.name ILIKE ? OR sc.email ILIKE ?))",
+			commenter, commenter,
+		)
 	}
 
 	if req.OrderBy == "created_at" {
@@ -114,6 +228,15 @@ func (s *Server) ListSpecs(c echo.Context) error {
 	if req.OrderBy == "updated_at" {
 		req.OrderBy = "google_doc_updated_at"
 	}
+	// specs without a declared date come last either way
+	orderNulls := ""
+	if req.OrderBy == "declared_created_at" {
+		orderNulls = " NULLS LAST"
+	}
+	if req.OrderBy == "last_reviewed_at" {
+		req.OrderBy = "(SELECT MAX(rev.reviewed_at) FROM reviewers rev WHERE rev.spec_id = specs.id)"
+		orderNulls = " NULLS LAST"
+	}
 
 	if req.SearchQuery != "" {
 		searchConfig := "english" // or any other language configuration
@@ -132,12 +255,14 @@ func (s *Server) ListSpecs(c echo.Context) error {
 			searchConfig,
 		)
 
-		query = query.Where(fmt.Sprintf("%s @@ %s", vectorExpr, queryExpr), req.SearchQuery)
+		search := s.DB.Where(fmt.Sprintf("%s @@ %s", vectorExpr, queryExpr), req.SearchQuery)
 
 		// also add ilike query for the same search fields
 		for _, field := range searchFields {
-			query = query.Or(fmt.Sprintf("%s ILIKE ?", field), "%"+req.SearchQuery+"%")
+			search = search.Or(fmt.Sprintf("%s ILIKE ?", field), "%"+req.SearchQuery+"%")
 		}
+		// grouped so the search cannot widen the other filters
+		query = query.Where(search)
 	}
 
 	var total int64
@@ -146,7 +271,10 @@ func (s *Server) ListSpecs(c echo.Context) error {
 	}
 
 	result := query.
-		Order(req.OrderBy + " " + req.OrderDir).
+		Preload("Reviewers", func(tx *gorm.DB) *gorm.DB {
+			return tx.Order("position")
+		}).
+		Order(req.OrderBy + " " + req.OrderDir + orderNulls).
 		Limit(int(req.Limit)).
 		Offset(int(req.Offset)).
 		Find(&specs)
@@ -155,6 +283,11 @@ func (s *Server) ListSpecs(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to fetch specs")
 	}
 
+	activities, commenters, err := s.commentActivity(specs)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to fetch comment activity")
+	}
+
 	specsList := ListSpecsResponse{
 		Total:  total,
 		Specs:  make([]Spec, len(specs)),
@@ -185,40 +318,249 @@ func (s *Server) ListSpecs(c echo.Context) error {
 		specsList.Specs[i].GoogleDocURL = spec.GoogleDocURL
 		specsList.Specs[i].GoogleDocCreatedAt = spec.GoogleDocCreatedAt
 		specsList.Specs[i].GoogleDocUpdatedAt = spec.GoogleDocUpdatedAt
+		specsList.Specs[i].SourceFormat = spec.SourceFormat
+		specsList.Specs[i].TemplateVersion = spec.TemplateVersion
+		specsList.Specs[i].DeclaredCreatedAt = spec.DeclaredCreatedAt
+		specsList.Specs[i].Reviewers = make([]Reviewer, len(spec.Reviewers))
+		for j, reviewer := range spec.Reviewers {
+			specsList.Specs[i].Reviewers[j] = Reviewer{
+				Name:       valueOf(reviewer.Name),
+				Email:      valueOf(reviewer.Email),
+				Status:     valueOf(reviewer.Status),
+				ReviewedAt: reviewer.ReviewedAt,
+			}
+		}
+		specsList.Specs[i].SharingLevel = spec.SharingLevel
+		specsList.Specs[i].ThumbnailURL = thumbnailURL(spec)
 		specsList.Specs[i].CreatedAt = spec.CreatedAt
 		specsList.Specs[i].UpdatedAt = spec.UpdatedAt
 		specsList.Specs[i].SyncedAt = spec.SyncedAt
+		if activity, ok := activities[spec.ID]; ok {
+			specsList.Specs[i].OpenComments = activity.OpenComments
+			specsList.Specs[i].ResolvedComments = activity.ResolvedComments
+			specsList.Specs[i].LastCommentAt = activity.LastCommentAt
+		}
+		specsList.Specs[i].Commenters = commenters[spec.ID]
+		if specsList.Specs[i].Commenters == nil {
+			specsList.Specs[i].Commenters = []string{}
+		}
 	}
 	return c.JSON(http.StatusOK, specsList)
 }
 
+// commentActivity loads the comment activity and the commenter names of the given specs
+func (s *Server) commentActivity(specs []db.Spec) (map[string]db.SpecCommentActivity, map[string][]string, error) {
+	specIDs := make([]string, len(specs))
+	for i, spec := range specs {
+		specIDs[i] = spec.ID
+	}
+
+	var activities []db.SpecCommentActivity
+	if err := s.DB.Where("spec_id IN ?", specIDs).Find(&activities).Error; err != nil {
+		return nil, nil, err
+	}
+	var commenters []db.SpecCommenter
+	if err := s.DB.Where("spec_id IN ?", specIDs).Order("last_comment_at DESC").Find(&commenters).Error; err != nil {
+		return nil, nil, err
+	}
+
+	activityBySpec := make(map[string]db.SpecCommentActivity, len(activities))
+	for _, activity := range activities {
+		activityBySpec[activity.SpecID] = activity
+	}
+	commentersBySpec := make(map[string][]string)
+	for _, commenter := range commenters {
+		name := commenter.Name
+		if name == "" {
+			name = commenter.Email
+		}
+		commentersBySpec[commenter.SpecID] = append(commentersBySpec[commenter.SpecID], name)
+	}
+	return activityBySpec, commentersBySpec, nil
+}
+
+// SpecHistory returns the status history of a spec, oldest period first
+func (s *Server) SpecHistory(c echo.Context) error {
+	specID := c.Param("id")
+	if err := s.findVisibleSpec(c, specID); err != nil {
+		return err
+	}
+
+	var periods []db.SpecStatusHistory
+	if err := s.DB.Where("spec_id = ?", specID).Order("started_at").Find(&periods).Error; err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to fetch spec history")
+	}
+
+	response := SpecHistoryResponse{
+		SpecID:  specID,
+		History: make([]SpecStatusPeriod, len(periods)),
+	}
+	for i, period := range periods {
+		response.History[i] = SpecStatusPeriod{
+			Status:     period.Status,
+			StartedAt:  period.StartedAt,
+			EndedAt:    period.EndedAt,
+			RevisionID: period.RevisionID,
+			ChangedBy:  period.ChangedBy,
+		}
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+// SpecStatusChanges returns the status changes of a spec, newest first
+func (s *Server) SpecStatusChanges(c echo.Context) error {
+	specID := c.Param("id")
+	if err := s.findVisibleSpec(c, specID); err != nil {
+		return err
+	}
+
+	var changes []db.SpecStatusChange
+	if err := s.DB.Where("spec_id = ?", specID).Order("changed_at DESC").Find(&changes).Error; err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to fetch spec status changes")
+	}
+
+	response := SpecStatusChangesResponse{
+		SpecID:  specID,
+		Changes: make([]SpecStatusChange, len(changes)),
+	}
+	for i, change := range changes {
+		response.Changes[i] = SpecStatusChange{
+			FromStatus: change.FromStatus,
+			ToStatus:   change.ToStatus,
+			ChangedAt:  change.ChangedAt,
+			Actor:      change.Actor,
+			ActorName:  change.ActorName,
+			Automated:  change.Automated,
+		}
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+// SpecPropertyConflicts returns the values the metadata table of a spec disagreed with
+// the app properties of its file on when it was last parsed
+func (s *Server) SpecPropertyConflicts(c echo.Context) error {
+	specID := c.Param("id")
+	if err := s.findVisibleSpec(c, specID); err != nil {
+		return err
+	}
+
+	var conflicts []db.SpecPropertyConflict
+	if err := s.DB.Where("spec_id = ?", specID).Order("key").Find(&conflicts).Error; err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to fetch spec property conflicts")
+	}
+
+	response := SpecPropertyConflictsResponse{
+		SpecID:    specID,
+		Conflicts: make([]SpecPropertyConflict, len(conflicts)),
+	}
+	for i, conflict := range conflicts {
+		response.Conflicts[i] = SpecPropertyConflict{
+			Key:        conflict.Key,
+			Table:      conflict.TableValue,
+			Property:   conflict.PropertyValue,
+			LastAction: conflict.LastAction,
+			DetectedAt: conflict.DetectedAt,
+		}
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
 func (s *Server) SpecAuthors(c echo.Context) error {
-	var uniqueAuthors []string
-	if err := s.DB.Model(&db.Spec{}).
-		Select("DISTINCT UNNEST(authors) as author").
-		Order("author").
-		Pluck("author", &uniqueAuthors).
-		Error; err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to fetch authors: "+err.Error())
+	authors, err := s.specPeopleNames(c, "spec_authors")
+	if err != nil {
+		return err
 	}
-	return c.JSON(http.StatusOK, uniqueAuthors)
+	return c.JSON(http.StatusOK, authors)
 }
 
 func (s *Server) SpecReviewers(c echo.Context) error {
-	var uniqueReviewers []string
-	if err := s.DB.Model(&db.Reviewer{}).
-		Select("DISTINCT Name as reviewer").
-		Order("reviewer").
-		Pluck("reviewer", &uniqueReviewers).
+	reviewers, err := s.specPeopleNames(c, "spec_reviewers")
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, reviewers)
+}
+
+func (s *Server) AuthorPeople(c echo.Context) error {
+	authors, err := s.specPeople(c, "spec_authors")
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, authors)
+}
+
+func (s *Server) ReviewerPeople(c echo.Context) error {
+	reviewers, err := s.specPeople(c, "spec_reviewers")
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, reviewers)
+}
+
+// specPeopleLinks selects the IDs of the people linked to the specs visible to the user
+// by a join table
+func (s *Server) specPeopleLinks(c echo.Context, joinTable string) (*gorm.DB, error) {
+	visible, err := s.visibleSpecIDs(c)
+	if err != nil {
+		return nil, err
+	}
+	links := s.DB.Table(joinTable).Select("person_id")
+	if visible != nil {
+		links = links.Where("spec_id IN (?)", visible)
+	}
+	return links, nil
+}
+
+// specPeopleNames lists the distinct names of the people linked to the visible specs,
+// the values the author and reviewer filters matched before people were stored
+func (s *Server) specPeopleNames(c echo.Context, joinTable string) ([]string, error) {
+	links, err := s.specPeopleLinks(c, joinTable)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	if err := s.DB.Model(&db.Person{}).
+		Where("id IN (?)", links).
+		Select("DISTINCT name").
+		Order("name").
+		Pluck("name", &names).
 		Error; err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to fetch reviewers: "+err.Error())
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, "Failed to fetch people: "+err.Error())
 	}
-	return c.JSON(http.StatusOK, uniqueReviewers)
+	return names, nil
+}
+
+// specPeople lists the people linked to the visible specs with their ID and email
+func (s *Server) specPeople(c echo.Context, joinTable string) ([]Person, error) {
+	links, err := s.specPeopleLinks(c, joinTable)
+	if err != nil {
+		return nil, err
+	}
+
+	var people []db.Person
+	if err := s.DB.Where("id IN (?)", links).Order("name, id").Find(&people).Error; err != nil {
+		return nil, echo.NewHTTPError(http.StatusInternalServerError, "Failed to fetch people: "+err.Error())
+	}
+	result := make([]Person, len(people))
+	for i, person := range people {
+		result[i] = Person{ID: person.ID, Name: person.Name, Email: person.Email}
+	}
+	return result, nil
 }
 
 func (s *Server) SpecTeams(c echo.Context) error {
+	visible, err := s.visibleSpecIDs(c)
+	if err != nil {
+		return err
+	}
+	query := s.DB.Model(&db.Spec{})
+	if visible != nil {
+		query = query.Where("id IN (?)", visible)
+	}
+
 	var uniqueTeams []string
-	if err := s.DB.Model(&db.Spec{}).
+	if err := query.
 		Select("DISTINCT team").
 		Order("team").
 		Pluck("team", &uniqueTeams).
@@ -227,3 +569,10 @@ func (s *Server) SpecTeams(c echo.Context) error {
 	}
 	return c.JSON(http.StatusOK, uniqueTeams)
 }
+
+func valueOf(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/canonical/specs-v2.canonical.com/db"
+	"github.com/labstack/echo/v4"
+)
+
+// thumbnailMaxAge is how long browsers may reuse a thumbnail, its URL changes with the
+// version of the file
+const thumbnailMaxAge = 7 * 24 * 60 * 60
+
+// thumbnailVersion identifies a cached thumbnail in its URL and ETag
+func thumbnailVersion(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:8])
+}
+
+// thumbnailURL returns the address of the thumbnail of a spec, empty without one
+func thumbnailURL(spec db.Spec) string {
+	if spec.ThumbnailKey == "" {
+		return ""
+	}
+	return fmt.Sprintf("/api/specs/%s/thumbnail?v=%s", spec.ID, thumbnailVersion(spec.ThumbnailKey))
+}
+
+// SpecThumbnail serves the cached Drive thumbnail of a spec. Drive thumbnail links only
+// work for users allowed to read the file, so they cannot be embedded in the UI.
+func (s *Server) SpecThumbnail(c echo.Context) error {
+	if s.Thumbnails == nil {
+		return echo.NewHTTPError(http.StatusNotFound, "Thumbnails are not enabled")
+	}
+	visible, err := s.visibleSpecIDs(c)
+	if err != nil {
+		return err
+	}
+
+	var spec db.Spec
+	query := s.DB.Select("id", "thumbnail_key").Where("id = ?", c.Param("id"))
+	if visible != nil {
+		query = query.Where("id IN (?)", visible)
+	}
+	if err := query.Limit(1).Find(&spec).Error; err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to fetch spec")
+	}
+	if spec.ThumbnailKey == "" {
+		return echo.NewHTTPError(http.StatusNotFound, "Thumbnail not found")
+	}
+
+	// thumbnails are private to the users allowed to see the spec, replacing the
+	// headers of NoCache so browsers, but no shared cache, keep them
+	header := c.Response().Header()
+	header.Del("Pragma")
+	header.Del("Expires")
+	header.Set("Cache-Control", fmt.Sprintf("private, max-age=%d", thumbnailMaxAge))
+	etag := `"` + thumbnailVersion(spec.ThumbnailKey) + `"`
+	header.Set("ETag", etag)
+	if c.Request().Header.Get("If-None-Match") == etag {
+		return c.NoContent(http.StatusNotModified)
+	}
+
+	body, err := s.Thumbnails.GetObject(c.Request().Context(), spec.ThumbnailKey)
+	if err != nil {
+		s.Logger.Error("failed to get thumbnail", "object_key", spec.ThumbnailKey, "error", err.Error())
+		return echo.NewHTTPError(http.StatusNotFound, "Thumbnail not found")
+	}
+	defer body.Close()
+
+	image, err := io.ReadAll(body)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to read thumbnail")
+	}
+	return c.Blob(http.StatusOK, http.DetectContentType(image), image)
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/canonical/specs-v2.canonical.com/db"
+	"github.com/canonical/specs-v2.canonical.com/specs"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// visibleSpecIDs returns a subquery of the IDs of the specs the signed-in user can open
+// in Drive, or nil when visibility is not enforced
+func (s *Server) visibleSpecIDs(c echo.Context) (*gorm.DB, error) {
+	principal, err := s.principal(c)
+	if principal == nil || err != nil {
+		return nil, err
+	}
+	return specs.VisibleSpecIDs(s.DB, *principal), nil
+}
+
+// findVisibleSpec returns a not found error unless the spec exists and the signed-in
+// user can see it
+func (s *Server) findVisibleSpec(c echo.Context, specID string) error {
+	visible, err := s.visibleSpecIDs(c)
+	if err != nil {
+		return err
+	}
+
+	var count int64
+	query := s.DB.Model(&db.Spec{}).Where("id = ?", specID)
+	if visible != nil {
+		query = query.Where("id IN (?)", visible)
+	}
+	if err := query.Count(&count).Error; err != nil {
+		return echo.NewHTTPError(http.StatusInternalServerError, "Failed to fetch spec")
+	}
+	if count == 0 {
+		return echo.NewHTTPError(http.StatusNotFound, "Spec not found")
+	}
+	return nil
+}
+
+// principal returns the signed-in user and their groups, or nil when visibility is not
+// enforced
+func (s *Server) principal(c echo.Context) (*specs.Principal, error) {
+	if !s.Config.IsVisibilityEnforced() {
+		return nil, nil
+	}
+
+	email, _ := c.Get("email").(string)
+	principal := specs.Principal{Email: email}
+	if s.Groups != nil {
+		groups, err := s.Groups.Groups(c.Request().Context(), email)
+		if err != nil {
+			s.Logger.Error("failed to resolve groups", "email", email, "error", err.Error())
+			return nil, echo.NewHTTPError(http.StatusInternalServerError, "Failed to resolve groups")
+		}
+		principal.Groups = groups
+	}
+	return &principal, nil
+}
@@ -0,0 +1,138 @@
+package specs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/canonical/specs-v2.canonical.com/db"
+	"github.com/canonical/specs-v2.canonical.com/google"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// terminalStatuses are the statuses a spec is archived in, lower case
+var terminalStatuses = []string{"approved", "completed", "rejected"}
+
+// ObjectStore keeps files such as the PDF snapshots and thumbnails of specs, it is
+// implemented by storage.S3Client and storage.DiskStore
+type ObjectStore interface {
+	PutObject(ctx context.Context, key, contentType string, body []byte) error
+	GetObject(ctx context.Context, key string) (io.ReadCloser, error)
+	DeleteObject(ctx context.Context, key string) error
+}
+
+// IsTerminalStatus reports whether specs in a status are archived
+func IsTerminalStatus(status string) bool {
+	status = strings.ToLower(strings.TrimSpace(status))
+	for _, terminal := range terminalStatuses {
+		if status == terminal {
+			return true
+		}
+	}
+	return false
+}
+
+// ArchiveObjectKey returns the key of the snapshot of a spec in a status, e.g.
+// "specs/EN001/approved/20240102T150405Z.pdf"
+func ArchiveObjectKey(prefix, specID, status string, archivedAt time.Time) string {
+	status = strings.ReplaceAll(strings.ToLower(strings.TrimSpace(status)), " ", "-")
+	return path.Join(prefix, specID, status, archivedAt.UTC().Format("20060102T150405Z")+".pdf")
+}
+
+// archiveIfTerminal stores a PDF snapshot of a spec that entered a terminal status, or
+// that is in one without having been archived in it yet, e.g. when archiving was
+// enabled after the spec was approved
+func (s *SyncService) archiveIfTerminal(ctx context.Context, logger *slog.Logger, spec *db.Spec, previousStatus string) error {
+	if s.Archive == nil || spec.Status == nil || !IsTerminalStatus(*spec.Status) {
+		return nil
+	}
+	status := strings.TrimSpace(*spec.Status)
+	if strings.EqualFold(strings.TrimSpace(previousStatus), status) {
+		var count int64
+		if err := s.DB.Model(&db.SpecArchive{}).
+			Where("spec_id = ? AND lower(status) = ?", spec.ID, strings.ToLower(status)).
+			Count(&count).Error; err != nil {
+			return fmt.Errorf("failed to look up archives: %w", err)
+		}
+		if count > 0 {
+			return nil
+		}
+	}
+
+	var content []byte
+	switch spec.SourceFormat {
+	case SourceFormatGoogleDoc, SourceFormatGoogleSheet:
+		pdf, err := s.GoogleClient.ExportFile(ctx, spec.GoogleDocID, google.MimeTypePDF)
+		if err != nil {
+			return fmt.Errorf("failed to export pdf: %w", err)
+		}
+		content = []byte(pdf)
+	case SourceFormatPDF:
+		pdf, err := s.GoogleClient.DownloadFile(ctx, spec.GoogleDocID)
+		if err != nil {
+			return fmt.Errorf("failed to download pdf: %w", err)
+		}
+		content = pdf
+	default:
+		logger.Debug("source format cannot be archived as pdf", "source_format", spec.SourceFormat)
+		return nil
+	}
+
+	archivedAt := time.Now()
+	archive := db.SpecArchive{
+		ID:                 uuid.NewString(),
+		SpecID:             spec.ID,
+		Status:             status,
+		ObjectKey:          ArchiveObjectKey(s.Config.ArchivePrefix, spec.ID, status, archivedAt),
+		Size:               int64(len(content)),
+		GoogleDocID:        spec.GoogleDocID,
+		GoogleDocUpdatedAt: spec.GoogleDocUpdatedAt,
+		ArchivedAt:         archivedAt,
+	}
+	if err := s.Archive.PutObject(ctx, archive.ObjectKey, google.MimeTypePDF, content); err != nil {
+		return err
+	}
+	if err := s.recordArchive(&archive); err != nil {
+		return err
+	}
+
+	logger.Info("archived spec", "status", status, "object_key", archive.ObjectKey, "size", archive.Size)
+	return nil
+}
+
+// recordArchive stores an archive with the readers of its spec at the time, which keep
+// seeing it once the spec and its permissions are gone
+func (s *SyncService) recordArchive(archive *db.SpecArchive) error {
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(archive).Error; err != nil {
+			return fmt.Errorf("failed to record archive: %w", err)
+		}
+
+		var permissions []db.SpecPermission
+		if err := tx.Where("spec_id = ?", archive.SpecID).Find(&permissions).Error; err != nil {
+			return fmt.Errorf("failed to query permissions: %w", err)
+		}
+		if len(permissions) == 0 {
+			return nil
+		}
+		readers := make([]db.SpecArchiveReader, len(permissions))
+		for i, permission := range permissions {
+			readers[i] = db.SpecArchiveReader{
+				ArchiveID:    archive.ID,
+				PermissionID: permission.ID,
+				Type:         permission.Type,
+				Email:        permission.Email,
+				Domain:       permission.Domain,
+			}
+		}
+		if err := tx.Create(&readers).Error; err != nil {
+			return fmt.Errorf("failed to record archive readers: %w", err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,141 @@
+package specs
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/canonical/specs-v2.canonical.com/db"
+	"github.com/canonical/specs-v2.canonical.com/google"
+	"github.com/google/uuid"
+	"google.golang.org/api/drive/v3"
+)
+
+// recordStatusChange records the change of status of a re-parsed spec since its
+// previous sync. With Config.AttributeStatusChanges the change is attributed to the
+// editor of the file in between.
+func (s *SyncService) recordStatusChange(
+	ctx context.Context,
+	logger *slog.Logger,
+	spec, previous *db.Spec,
+	file *drive.File,
+) error {
+	if previous.Status == nil {
+		return nil
+	}
+	from := strings.TrimSpace(*previous.Status)
+	to := strings.TrimSpace(valueOf(spec.Status))
+	if strings.EqualFold(from, to) {
+		return nil
+	}
+
+	change := db.SpecStatusChange{
+		ID:         uuid.NewString(),
+		SpecID:     spec.ID,
+		FromStatus: from,
+		ToStatus:   to,
+		ChangedAt:  spec.GoogleDocUpdatedAt,
+		DetectedAt: time.Now(),
+	}
+	// automation actions record the status they set along with the content they wrote
+	props := PropertiesOf(file)
+	if props.Current(file) && props.LastAction != "" && strings.EqualFold(props.Status, to) {
+		change.Automated = true
+	}
+	if s.Config.AttributeStatusChanges {
+		if err := s.attributeStatusChange(ctx, &change, previous, spec, file); err != nil {
+			logger.Warn("failed to attribute status change", "error", err.Error())
+		}
+	}
+
+	if err := s.DB.Create(&change).Error; err != nil {
+		return fmt.Errorf("failed to insert status change: %w", err)
+	}
+	logger.Info("spec status changed",
+		"from", from,
+		"to", to,
+		"actor", change.Actor,
+		"automated", change.Automated,
+	)
+	return nil
+}
+
+// attributeStatusChange sets the actor and time of a status change from the edits of a
+// file made between the modified time seen by the last sync of the old status and the
+// one of the first sync of the new status. Specs refreshed from their app properties keep
+// the modified time of their last export, so the window also starts after the previous
+// status change. Drive Activity does not tell which part of
+// the Doc an edit touched, so the change is only credited when a single person edited
+// in that window, with the time of their last edit, the closest to the sync that saw the
+// new status. Actors are resolved to an email through the permissions of the file,
+// people reaching it through a group or a domain keep their Drive Activity person name.
+func (s *SyncService) attributeStatusChange(
+	ctx context.Context,
+	change *db.SpecStatusChange,
+	previous, spec *db.Spec,
+	file *drive.File,
+) error {
+	after := previous.GoogleDocUpdatedAt
+	var last db.SpecStatusChange
+	if err := s.DB.Select("changed_at").Where("spec_id = ?", spec.ID).
+		Order("changed_at DESC").Limit(1).Find(&last).Error; err != nil {
+		return fmt.Errorf("failed to find previous status change: %w", err)
+	}
+	if last.ChangedAt.After(after) {
+		after = last.ChangedAt
+	}
+
+	activities, err := s.GoogleClient.QueryEditActivity(ctx, file.Id, after, spec.GoogleDocUpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to query activity: %w", err)
+	}
+
+	var (
+		personName    string
+		isCurrentUser bool
+		changedAt     time.Time
+	)
+	for _, activity := range activities {
+		actor, current := google.ActivityActor(activity)
+		at, err := google.ActivityTime(activity)
+		if err != nil {
+			continue
+		}
+		// anonymous and deleted editors may have made the change as well
+		if actor == "" || (personName != "" && actor != personName) {
+			return nil
+		}
+		if at.After(changedAt) {
+			changedAt = at
+		}
+		personName, isCurrentUser = actor, current
+	}
+	if personName == "" {
+		return nil
+	}
+	change.ChangedAt = changedAt
+	change.Actor = personName
+	change.Automated = change.Automated || isCurrentUser
+
+	permissions := file.Permissions
+	if permissions == nil {
+		permissions, err = s.GoogleClient.ListPermissions(ctx, file.Id)
+		if err != nil {
+			return fmt.Errorf("failed to list permissions: %w", err)
+		}
+	}
+	for _, permission := range permissions {
+		if permission.Type == "user" && permission.Id == google.PersonPermissionID(personName) {
+			change.Actor = strings.ToLower(permission.EmailAddress)
+			change.ActorName = permission.DisplayName
+			break
+		}
+	}
+	if slices.Contains(s.Config.AutomationEmails, change.Actor) {
+		change.Automated = true
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package specs
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/canonical/specs-v2.canonical.com/db"
+	"gorm.io/gorm"
+)
+
+// TeamMembers maps team names to the emails of their members and groups
+type TeamMembers map[string][]string
+
+// LoadTeamMembers reads team members from a JSON file such as
+// {"Engineering": ["jane@canonical.com", "eng@canonical.com"]}
+func LoadTeamMembers(path string) (TeamMembers, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read team members file: %w", err)
+	}
+	var members TeamMembers
+	if err := json.Unmarshal(data, &members); err != nil {
+		return nil, fmt.Errorf("failed to parse team members file: %w", err)
+	}
+	return members, nil
+}
+
+// has reports whether an email belongs to a member of a team
+func (m TeamMembers) has(team, email string) bool {
+	for _, member := range m[team] {
+		if strings.EqualFold(member, email) {
+			return true
+		}
+	}
+	return false
+}
+
+// SharingFinding is a spec shared outside the company or its owning team
+type SharingFinding struct {
+	SpecID       string `json:"spec_id"`
+	Title        string `json:"title"`
+	Team         string `json:"team"`
+	URL          string `json:"url"`
+	SharingLevel string `json:"sharing_level"`
+	// External lists the grantees outside the company domains, "anyone" for link sharing
+	External []string `json:"external"`
+	// OutsideTeam lists the people and groups of the company that are not members of the
+	// owning team, only for teams listed in the team members
+	OutsideTeam []string `json:"outside_team"`
+}
+
+// SharingReport lists the specs shared outside the company domains, or with people
+// outside their owning team when its members are known. The widest shared specs come
+// first, then specs are ordered by team and ID.
+func SharingReport(tx *gorm.DB, members TeamMembers) ([]SharingFinding, error) {
+	var specs []db.Spec
+	if err := tx.Order("team, id").Find(&specs).Error; err != nil {
+		return nil, fmt.Errorf("failed to query specs: %w", err)
+	}
+	var permissions []db.SpecPermission
+	if err := tx.Order("spec_id, email, domain").Find(&permissions).Error; err != nil {
+		return nil, fmt.Errorf("failed to query permissions: %w", err)
+	}
+
+	permissionsBySpec := make(map[string][]db.SpecPermission)
+	for _, permission := range permissions {
+		permissionsBySpec[permission.SpecID] = append(permissionsBySpec[permission.SpecID], permission)
+	}
+
+	var findings []SharingFinding
+	for _, spec := range specs {
+		finding := SharingFinding{
+			SpecID:       spec.ID,
+			Team:         spec.Team,
+			URL:          spec.GoogleDocURL,
+			SharingLevel: spec.SharingLevel,
+		}
+		if spec.Title != nil {
+			finding.Title = *spec.Title
+		}
+
+		_, knownTeam := members[spec.Team]
+		for _, permission := range permissionsBySpec[spec.ID] {
+			grantee := permissionGrantee(permission)
+			switch {
+			case permission.External:
+				finding.External = append(finding.External, grantee)
+			case knownTeam && (permission.Type == "user" || permission.Type == "group") &&
+				!members.has(spec.Team, permission.Email):
+				finding.OutsideTeam = append(finding.OutsideTeam, grantee)
+			}
+		}
+
+		if len(finding.External) > 0 || len(finding.OutsideTeam) > 0 {
+			findings = append(findings, finding)
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		return sharingLevelRank[findings[i].SharingLevel] > sharingLevelRank[findings[j].SharingLevel]
+	})
+	return findings, nil
+}
+
+// permissionGrantee names who a permission grants access to
+func permissionGrantee(permission db.SpecPermission) string {
+	switch permission.Type {
+	case "anyone":
+		return "anyone"
+	case "domain":
+		return permission.Domain
+	}
+	return permission.Email
+}
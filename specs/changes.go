@@ -0,0 +1,365 @@
+package specs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/canonical/specs-v2.canonical.com/db"
+	"github.com/canonical/specs-v2.canonical.com/google"
+	"google.golang.org/api/drive/v3"
+	"gorm.io/gorm"
+)
+
+// syncStateID identifies the sync state row of the Drive changes feed
+const syncStateID = "drive_changes"
+
+// errFullSyncRequired is returned while processing changes that cannot be applied
+// incrementally, such as a folder being moved or renamed
+var errFullSyncRequired = errors.New("full sync required")
+
+// folderLocation is the resolved position of a folder within the spec tree
+type folderLocation struct {
+	item   folderItem
+	inTree bool
+}
+
+// SyncChanges re-parses only the Docs that changed, moved or were trashed since the
+// last run, using the Drive Changes API. It falls back to a full SyncSpecs when no page
+// token was stored yet, when the last full reconciliation is older than
+// FullSyncInterval, or when a folder of the spec tree changed. Docs moved out of the
+// tree, with their folder or through a trashed shortcut, are removed by the next full
+// reconciliation, as they may still be reachable another way. The page token is kept
+// when a Doc failed, so its change is listed again by the next run.
+func (s *SyncService) SyncChanges(ctx context.Context) error {
+	state, err := s.loadSyncState()
+	if err != nil {
+		return fmt.Errorf("failed to load sync state: %w", err)
+	}
+	if state == nil {
+		s.Logger.Info("no changes page token stored, running full sync")
+		return s.SyncSpecs(ctx)
+	}
+	if s.Config.FullSyncInterval > 0 && time.Since(state.FullSyncAt) > s.Config.FullSyncInterval {
+		s.Logger.Info("running periodic full reconciliation", "last_full_sync_at", state.FullSyncAt)
+		return s.SyncSpecs(ctx)
+	}
+
+	s.Logger.Info("starting incremental specs synchronization")
+	s.FailedCount.Store(0)
+	s.SkippedCount.Store(0)
+	s.ConflictCount.Store(0)
+	startTime := time.Now()
+
+	var (
+		newPageToken string
+		walkErr      error
+		totalCount   int32
+		deletedCount int
+		// failed is set when a change could not be applied
+		failed atomic.Bool
+	)
+	locations := map[string]*folderLocation{}
+
+	s.runWorkers(ctx, func(workerItems chan<- *WorkerItem) {
+		seenDocs := map[string]bool{}
+
+		// stops the listing when returning before its end
+		listCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		for result := range s.GoogleClient.ListChangesChannel(listCtx, state.PageToken) {
+			if result.Err != nil {
+				walkErr = result.Err
+				return
+			}
+			if result.NewStartPageToken != "" {
+				newPageToken = result.NewStartPageToken
+				return
+			}
+
+			change := result.Change
+			if change.ChangeType != "" && change.ChangeType != "file" {
+				continue
+			}
+			logger := s.Logger.With("file_id", change.FileId)
+
+			file := change.File
+			if file != nil && file.MimeType == google.MimeTypeFolder {
+				inTree, err := s.folderInTree(ctx, file, locations)
+				if err != nil {
+					logger.Error("failed to locate folder", "error", err.Error())
+					failed.Store(true)
+					continue
+				}
+				if inTree {
+					logger.Info("folder changed, falling back to full sync", "folder_name", file.Name)
+					walkErr = errFullSyncRequired
+					return
+				}
+				continue
+			}
+			// the target of a trashed shortcut may still be in the tree
+			if file != nil && file.Trashed && file.MimeType == google.MimeTypeShortcut {
+				logger.Debug("shortcut trashed, leaving its target to the full sync")
+				continue
+			}
+			if change.Removed || file == nil || file.Trashed {
+				deleted, err := s.deleteSpecsByGoogleDocID(change.FileId)
+				if err != nil {
+					logger.Error("failed to delete removed spec", "error", err.Error())
+					failed.Store(true)
+				}
+				deletedCount += deleted
+				continue
+			}
+
+			// the location of the file itself decides the team, even for shortcuts
+			placed := file
+			switch file.MimeType {
+			case google.MimeTypeShortcut:
+				target, err := s.resolveShortcut(ctx, file)
+				if err != nil {
+					logger.Error("failed to resolve shortcut", "error", err.Error())
+					failed.Store(true)
+					continue
+				}
+				if target == nil {
+					continue
+				}
+				file = target
+			default:
+				if s.Extractors.For(file.MimeType) == nil {
+					continue
+				}
+			}
+
+			if seenDocs[file.Id] {
+				continue
+			}
+			seenDocs[file.Id] = true
+
+			item, err := s.workerItemFor(ctx, placed, file, locations)
+			if err != nil {
+				logger.Error("failed to locate document", "error", err.Error())
+				failed.Store(true)
+				continue
+			}
+			if item == nil {
+				logger.Debug("skipping document outside the spec tree")
+				continue
+			}
+			item.Done = func(err error) {
+				if err != nil {
+					failed.Store(true)
+				}
+			}
+
+			atomic.AddInt32(&totalCount, 1)
+			select {
+			case workerItems <- item:
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+
+	if errors.Is(walkErr, errFullSyncRequired) {
+		return s.SyncSpecs(ctx)
+	}
+	if walkErr != nil {
+		return fmt.Errorf("failed to list changes: %w", walkErr)
+	}
+
+	switch {
+	case failed.Load():
+		s.Logger.Warn("keeping the changes page token to retry the failed documents")
+	case newPageToken != "" && ctx.Err() == nil:
+		if err := s.saveSyncState(newPageToken, state.FullSyncAt); err != nil {
+			return fmt.Errorf("failed to save sync state: %w", err)
+		}
+	}
+
+	s.Logger.Info("incremental specs synchronization completed",
+		"duration", time.Since(startTime).Seconds(),
+		"total_count", totalCount,
+		"deleted_count", deletedCount,
+		"failed_count", s.FailedCount.Load(),
+		"skipped_count", s.SkippedCount.Load(),
+		"conflict_count", s.ConflictCount.Load(),
+	)
+
+	return ctx.Err()
+}
+
+// workerItemFor builds the worker item for a changed Doc, taking the team from where
+// placed (the Doc itself or a shortcut to it) sits in the spec tree. It returns nil for
+// Docs that are neither in the tree nor already indexed.
+func (s *SyncService) workerItemFor(
+	ctx context.Context,
+	placed *drive.File,
+	file *drive.File,
+	locations map[string]*folderLocation,
+) (*WorkerItem, error) {
+	location, err := s.locate(ctx, placed, locations)
+	if err != nil {
+		return nil, err
+	}
+	if location == nil {
+		// Docs outside the tree may still be synced through a shortcut, so keep
+		// known specs up to date and leave removals to the full reconciliation
+		location = s.knownLocation(file.Id)
+	}
+	if location == nil {
+		return nil, nil
+	}
+
+	return &WorkerItem{
+		File:         google.FileResult{File: file},
+		ParentFolder: location.Folder,
+		Team:         location.Team,
+	}, nil
+}
+
+// folderInTree reports whether a changed folder is a source root or sits in the spec
+// tree within MaxDepth, where its contents or its name may decide which Docs are synced
+// and their team
+func (s *SyncService) folderInTree(ctx context.Context, folder *drive.File, locations map[string]*folderLocation) (bool, error) {
+	if _, ok := s.sourceAt(folder.Id); ok {
+		return true, nil
+	}
+	parent, err := s.locate(ctx, folder, locations)
+	if err != nil {
+		return false, err
+	}
+	return parent != nil && parent.Depth+1 <= s.Config.MaxDepth, nil
+}
+
+// locate finds the folder a changed file belongs to in the spec tree by walking up its
+// parents. It returns nil if none of the parents lead to a source root within MaxDepth.
+func (s *SyncService) locate(
+	ctx context.Context,
+	file *drive.File,
+	locations map[string]*folderLocation,
+) (*folderItem, error) {
+	for _, parentID := range file.Parents {
+		location, err := s.locateFolder(ctx, parentID, locations, 0)
+		if err != nil {
+			return nil, err
+		}
+		if location.inTree {
+			return &location.item, nil
+		}
+	}
+	return nil, nil
+}
+
+// locateFolder resolves a folder's team and depth below a source root, caching folders
+// found in the tree for the duration of a sync. Folders outside of it are not cached, as
+// the hop limit depends on the Doc the lookup started from.
+func (s *SyncService) locateFolder(
+	ctx context.Context,
+	folderID string,
+	locations map[string]*folderLocation,
+	hops int,
+) (*folderLocation, error) {
+	if location, ok := locations[folderID]; ok {
+		return location, nil
+	}
+	if source, ok := s.sourceAt(folderID); ok {
+		root, err := s.rootFolderItem(ctx, source)
+		if err != nil {
+			return nil, err
+		}
+		location := &folderLocation{item: root, inTree: true}
+		locations[folderID] = location
+		return location, nil
+	}
+
+	// Do not walk further up than a folder in the tree could be
+	location := &folderLocation{}
+	if hops >= s.Config.MaxDepth {
+		return location, nil
+	}
+
+	folder, err := s.GoogleClient.GetFile(ctx, folderID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, parentID := range folder.Parents {
+		parent, err := s.locateFolder(ctx, parentID, locations, hops+1)
+		if err != nil {
+			return nil, err
+		}
+		if !parent.inTree || parent.item.Depth+1 > s.Config.MaxDepth {
+			continue
+		}
+		location = &folderLocation{
+			item: folderItem{
+				Folder: google.FileResult{File: folder},
+				Team:   s.folderTeam(folder, parent.item),
+				Depth:  parent.item.Depth + 1,
+				Source: parent.item.Source,
+			},
+			inTree: true,
+		}
+		locations[folderID] = location
+		break
+	}
+
+	return location, nil
+}
+
+// knownLocation returns the team a Doc was last synced with, or nil if it is not indexed
+func (s *SyncService) knownLocation(googleDocID string) *folderItem {
+	var spec db.Spec
+	if err := s.DB.Select("team").Where("google_doc_id = ?", googleDocID).Take(&spec).Error; err != nil {
+		return nil
+	}
+	return &folderItem{
+		Folder: google.FileResult{File: &drive.File{Name: spec.Team}},
+		Team:   spec.Team,
+	}
+}
+
+// deleteSpecsByGoogleDocID removes the specs synced from a Doc, their reviewers, comment
+// activity and permissions
+func (s *SyncService) deleteSpecsByGoogleDocID(googleDocID string) (int, error) {
+	var deleted int64
+	err := s.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("google_doc_id = ?", googleDocID).Delete(&db.Spec{})
+		if result.Error != nil {
+			return result.Error
+		}
+		deleted = result.RowsAffected
+		return deleteOrphanedSpecData(tx)
+	})
+	return int(deleted), err
+}
+
+// loadSyncState returns the stored sync state, or nil if none was saved yet
+func (s *SyncService) loadSyncState() (*db.SyncState, error) {
+	var state db.SyncState
+	err := s.DB.Where("id = ?", syncStateID).First(&state).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// saveSyncState stores the page token to resume listing changes from
+func (s *SyncService) saveSyncState(pageToken string, fullSyncAt time.Time) error {
+	state := db.SyncState{
+		ID:         syncStateID,
+		PageToken:  pageToken,
+		FullSyncAt: fullSyncAt,
+		UpdatedAt:  time.Now(),
+	}
+	return s.DB.Save(&state).Error
+}
@@ -0,0 +1,94 @@
+package specs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/canonical/specs-v2.canonical.com/db"
+	"github.com/google/uuid"
+	"google.golang.org/api/drive/v3"
+	"gorm.io/gorm"
+)
+
+// syncComments replaces the comment activity of a spec with the comments currently on
+// its file. Replies count towards the commenters and the last comment time, but not
+// towards the open and resolved threads.
+func (s *SyncService) syncComments(ctx context.Context, specID, fileID string) error {
+	activity := db.SpecCommentActivity{SpecID: specID, SyncedAt: time.Now()}
+	commenters := map[string]*db.SpecCommenter{}
+
+	record := func(author *drive.User, createdTime string) {
+		postedAt, err := time.Parse(time.RFC3339, createdTime)
+		if err != nil {
+			return
+		}
+		if activity.LastCommentAt == nil || postedAt.After(*activity.LastCommentAt) {
+			activity.LastCommentAt = &postedAt
+		}
+		if author == nil {
+			return
+		}
+
+		// Drive only shares the email of some authors
+		key := author.EmailAddress
+		if key == "" {
+			key = author.DisplayName
+		}
+		commenter, ok := commenters[key]
+		if !ok {
+			commenter = &db.SpecCommenter{
+				ID:     uuid.NewString(),
+				SpecID: specID,
+				Name:   author.DisplayName,
+				Email:  author.EmailAddress,
+			}
+			commenters[key] = commenter
+		}
+		commenter.Comments++
+		if postedAt.After(commenter.LastCommentAt) {
+			commenter.LastCommentAt = postedAt
+		}
+	}
+
+	for result := range s.GoogleClient.ListCommentsChannel(ctx, fileID) {
+		if result.Err != nil {
+			return fmt.Errorf("failed to list comments: %w", result.Err)
+		}
+
+		comment := result.Comment
+		if comment.Resolved {
+			activity.ResolvedComments++
+		} else {
+			activity.OpenComments++
+		}
+		record(comment.Author, comment.CreatedTime)
+		for _, reply := range comment.Replies {
+			if !reply.Deleted {
+				record(reply.Author, reply.CreatedTime)
+			}
+		}
+	}
+
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&activity).Error; err != nil {
+			return fmt.Errorf("failed to save comment activity: %w", err)
+		}
+		if err := tx.Where("spec_id = ?", specID).Delete(&db.SpecCommenter{}).Error; err != nil {
+			return fmt.Errorf("failed to clear old commenters: %w", err)
+		}
+		for _, commenter := range commenters {
+			if err := tx.Create(commenter).Error; err != nil {
+				return fmt.Errorf("failed to insert commenter: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// hasCommentActivity reports whether the comments of a spec were synced before
+func (s *SyncService) hasCommentActivity(specID string) bool {
+	var count int64
+	s.DB.Model(&db.SpecCommentActivity{}).Where("spec_id = ?", specID).Count(&count)
+	return count > 0
+}
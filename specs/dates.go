@@ -0,0 +1,59 @@
+package specs
+
+import (
+	"strings"
+	"time"
+)
+
+// declaredDateLayouts are the date formats found in metadata tables: typed dates and the
+// formats date chips are rendered in, the day of the week being trimmed beforehand
+var declaredDateLayouts = []string{
+	"2006-01-02",
+	"2006/01/02",
+	"Jan 2, 2006",
+	"Jan 2 2006",
+	"January 2, 2006",
+	"January 2 2006",
+	"2 Jan 2006",
+	"2 January 2006",
+	"Jan 2006",
+	"January 2006",
+	"1/2/2006",
+	"2/1/2006",
+	"2.1.2006",
+	time.RFC3339,
+}
+
+// parseDeclaredDate reads a date written in a metadata table, e.g. "Apr 22, 2021",
+// "2021-09-13" or the "Monday, September 13, 2021" of a date chip. Slashed dates are
+// read month first, like the default format of date chips, unless that cannot be a date.
+// ok is false for empty and unreadable values.
+func parseDeclaredDate(value string) (date time.Time, ok bool) {
+	value = strings.Join(strings.Fields(value), " ")
+	if value == "" {
+		return time.Time{}, false
+	}
+	// "Monday, September 13, 2021"
+	if weekday, rest, found := strings.Cut(value, ", "); found && isWeekday(weekday) {
+		value = rest
+	}
+	// "Sept 13, 2021" is how some locales abbreviate September
+	value = strings.Replace(value, "Sept ", "Sep ", 1)
+
+	for _, layout := range declaredDateLayouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// isWeekday reports whether a word is a full or abbreviated day of the week
+func isWeekday(word string) bool {
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if strings.EqualFold(word, day.String()) || strings.EqualFold(word, day.String()[:3]) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,393 @@
+package specs
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/canonical/specs-v2.canonical.com/db"
+	"github.com/canonical/specs-v2.canonical.com/google"
+	"google.golang.org/api/drive/v3"
+)
+
+// Source formats recorded on db.Spec
+const (
+	SourceFormatGoogleDoc   = "google_doc"
+	SourceFormatGoogleSheet = "google_sheet"
+	SourceFormatDocx        = "docx"
+	SourceFormatMarkdown    = "markdown"
+	SourceFormatPDF         = "pdf"
+)
+
+// Extractor reads the metadata of a spec file of a given format into a spec. The ID and
+// title taken from the file name are already set on the spec.
+type Extractor interface {
+	// Format is the source format recorded on the specs it extracts
+	Format() string
+	Extract(ctx context.Context, client google.Backend, file *drive.File, spec *db.Spec) error
+}
+
+// ExtractorRegistry maps MIME types to the extractor handling them
+type ExtractorRegistry map[string]Extractor
+
+// extractorsByFormat lists the MIME types handled by the extractor of each source format
+var extractorsByFormat = map[string]struct {
+	extractor Extractor
+	mimeTypes []string
+}{
+	SourceFormatGoogleDoc:   {googleDocExtractor{}, []string{google.MimeTypeDocument}},
+	SourceFormatGoogleSheet: {googleSheetExtractor{}, []string{google.MimeTypeSheet}},
+	SourceFormatDocx:        {docxExtractor{}, []string{google.MimeTypeDocx}},
+	SourceFormatMarkdown:    {markdownExtractor{}, []string{google.MimeTypeMarkdown, google.MimeTypeMarkdownX}},
+	SourceFormatPDF:         {pdfExtractor{}, []string{google.MimeTypePDF}},
+}
+
+// NewExtractorRegistry returns a registry with the extractors of the given source
+// formats, or of Google Docs only when none are given
+func NewExtractorRegistry(formats ...string) (ExtractorRegistry, error) {
+	if len(formats) == 0 {
+		formats = []string{SourceFormatGoogleDoc}
+	}
+
+	registry := ExtractorRegistry{}
+	for _, format := range formats {
+		entry, ok := extractorsByFormat[format]
+		if !ok {
+			return nil, fmt.Errorf("unknown spec source format %q", format)
+		}
+		for _, mimeType := range entry.mimeTypes {
+			registry.Register(mimeType, entry.extractor)
+		}
+	}
+	return registry, nil
+}
+
+// Register sets the extractor of a MIME type, replacing any previous one
+func (r ExtractorRegistry) Register(mimeType string, extractor Extractor) {
+	r[mimeType] = extractor
+}
+
+// For returns the extractor of a MIME type, or nil if the type is not synced
+func (r ExtractorRegistry) For(mimeType string) Extractor {
+	return r[mimeType]
+}
+
+// MimeTypes returns the registered MIME types, sorted
+func (r ExtractorRegistry) MimeTypes() []string {
+	mimeTypes := make([]string, 0, len(r))
+	for mimeType := range r {
+		mimeTypes = append(mimeTypes, mimeType)
+	}
+	sort.Strings(mimeTypes)
+	return mimeTypes
+}
+
+// applyMetadataTable fills a spec from a metadata table with the parser of the template
+// version matching it best, and records that version
+func applyMetadataTable(table [][]string, spec *db.Spec) error {
+	if len(table) == 0 {
+		return fmt.Errorf("metadata table is empty")
+	}
+
+	parser := MetadataParsers.Detect(table)
+	if parser == nil {
+		return fmt.Errorf("metadata table matches no known template")
+	}
+	spec.TemplateVersion = parser.Version()
+	parser.Parse(table, spec)
+	return nil
+}
+
+// googleDocExtractor reads the first table of a Google Doc through the Docs API
+type googleDocExtractor struct{}
+
+func (googleDocExtractor) Format() string { return SourceFormatGoogleDoc }
+
+func (googleDocExtractor) Extract(ctx context.Context, client google.Backend, file *drive.File, spec *db.Spec) error {
+	table, err := client.DocumentTable(ctx, file.Id)
+	if err != nil {
+		return fmt.Errorf("failed to get first table: %w", err)
+	}
+	return applyMetadataTable(table.Strings(), spec)
+}
+
+// googleSheetExtractor reads the first sheet of a Google Sheet as the metadata table
+type googleSheetExtractor struct{}
+
+func (googleSheetExtractor) Format() string { return SourceFormatGoogleSheet }
+
+func (googleSheetExtractor) Extract(ctx context.Context, client google.Backend, file *drive.File, spec *db.Spec) error {
+	content, err := client.ExportFile(ctx, file.Id, google.MimeTypeCSV)
+	if err != nil {
+		return fmt.Errorf("failed to export sheet: %w", err)
+	}
+
+	reader := csv.NewReader(strings.NewReader(content))
+	reader.FieldsPerRecord = -1
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return fmt.Errorf("failed to read sheet: %w", err)
+	}
+
+	table := [][]string{}
+	for _, row := range rows {
+		for i := range row {
+			row[i] = strings.TrimSpace(row[i])
+		}
+		table = append(table, row)
+	}
+	return applyMetadataTable(table, spec)
+}
+
+// docxExtractor reads the first table of an uploaded Word document
+type docxExtractor struct{}
+
+func (docxExtractor) Format() string { return SourceFormatDocx }
+
+func (docxExtractor) Extract(ctx context.Context, client google.Backend, file *drive.File, spec *db.Spec) error {
+	content, err := client.DownloadFile(ctx, file.Id)
+	if err != nil {
+		return fmt.Errorf("failed to download document: %w", err)
+	}
+
+	tables, err := docxTables(content)
+	if err != nil {
+		return err
+	}
+	return applyMetadataTable(tables[google.MetadataTableIndex(tables)], spec)
+}
+
+// docxTables extracts the top level tables of a .docx file, in order. Paragraphs within
+// a cell are joined with commas.
+func docxTables(content []byte) ([][][]string, error) {
+	archive, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open document: %w", err)
+	}
+
+	part, err := archive.Open("word/document.xml")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open document body: %w", err)
+	}
+	defer part.Close()
+
+	var (
+		tables     [][][]string
+		table      [][]string
+		row        []string
+		paragraphs []string
+		text       strings.Builder
+		depth      int
+		inText     bool
+	)
+
+	decoder := xml.NewDecoder(part)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse document body: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "tbl":
+				depth++
+			case "tr":
+				if depth == 1 {
+					row = []string{}
+				}
+			case "tc":
+				if depth == 1 {
+					paragraphs = nil
+				}
+			case "p":
+				text.Reset()
+			case "t":
+				inText = depth == 1
+			}
+		case xml.CharData:
+			if inText {
+				text.Write(t)
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				if depth == 1 {
+					if paragraph := strings.TrimSpace(text.String()); paragraph != "" {
+						paragraphs = append(paragraphs, paragraph)
+					}
+				}
+			case "tc":
+				if depth == 1 {
+					row = append(row, strings.Join(paragraphs, ","))
+				}
+			case "tr":
+				if depth == 1 {
+					table = append(table, row)
+				}
+			case "tbl":
+				depth--
+				if depth == 0 {
+					tables = append(tables, table)
+					table = nil
+				}
+			}
+		}
+	}
+
+	if len(tables) == 0 {
+		return nil, fmt.Errorf("no table found in the document")
+	}
+	return tables, nil
+}
+
+// markdownExtractor reads the front matter of an uploaded Markdown file, or its metadata
+// table when it has none
+type markdownExtractor struct{}
+
+func (markdownExtractor) Format() string { return SourceFormatMarkdown }
+
+func (markdownExtractor) Extract(ctx context.Context, client google.Backend, file *drive.File, spec *db.Spec) error {
+	content, err := client.DownloadFile(ctx, file.Id)
+	if err != nil {
+		return fmt.Errorf("failed to download markdown: %w", err)
+	}
+
+	if table := markdownFrontMatter(string(content)); len(table) > 0 {
+		parseRowBasedMetadata(table, spec)
+		return nil
+	}
+	tables := markdownTables(string(content))
+	if len(tables) == 0 {
+		return fmt.Errorf("no table found in the markdown")
+	}
+	return applyMetadataTable(tables[google.MetadataTableIndex(tables)], spec)
+}
+
+// markdownFrontMatter returns the "key: value" pairs of a front matter block as rows of
+// a row based metadata table. Values given as a YAML list are joined with commas.
+func markdownFrontMatter(content string) [][]string {
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	if !scanner.Scan() || strings.TrimSpace(scanner.Text()) != "---" {
+		return nil
+	}
+
+	var table [][]string
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t")
+		if line == "---" || line == "..." {
+			return table
+		}
+
+		// items of a block list belong to the previous key
+		if item, ok := strings.CutPrefix(strings.TrimSpace(line), "- "); ok && len(table) > 0 {
+			last := table[len(table)-1]
+			last[1] = strings.TrimPrefix(last[1]+","+unquote(item), ",")
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		if strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]") {
+			items := strings.Split(strings.Trim(value, "[]"), ",")
+			for i := range items {
+				items[i] = unquote(items[i])
+			}
+			value = strings.Join(items, ",")
+		}
+		table = append(table, []string{normalizeMetadataKey(key), unquote(value)})
+	}
+
+	// an unterminated block is not front matter
+	return nil
+}
+
+var markdownLinkPattern = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+
+// markdownTables returns the cells of the pipe tables of a Markdown document, in order
+func markdownTables(content string) [][][]string {
+	var (
+		tables [][][]string
+		table  [][]string
+	)
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "|") {
+			if len(table) > 0 {
+				tables = append(tables, table)
+				table = nil
+			}
+			continue
+		}
+
+		cells := strings.Split(strings.Trim(line, "|"), "|")
+		separator := true
+		for i, cell := range cells {
+			cell = strings.TrimSpace(markdownLinkPattern.ReplaceAllString(cell, "$1"))
+			cells[i] = strings.ReplaceAll(cell, "**", "")
+			if strings.Trim(cell, ":-") != "" || cell == "" {
+				separator = false
+			}
+		}
+		if !separator {
+			table = append(table, cells)
+		}
+	}
+	if len(table) > 0 {
+		tables = append(tables, table)
+	}
+	return tables
+}
+
+// pdfExtractor takes the metadata of a PDF from its Drive description, written as
+// "key: value" lines such as "Status: Approved", as PDFs have no reliable structure
+type pdfExtractor struct{}
+
+func (pdfExtractor) Format() string { return SourceFormatPDF }
+
+func (pdfExtractor) Extract(ctx context.Context, client google.Backend, file *drive.File, spec *db.Spec) error {
+	var table [][]string
+	for _, line := range strings.Split(file.Description, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		table = append(table, []string{normalizeMetadataKey(key), strings.TrimSpace(value)})
+	}
+	parseRowBasedMetadata(table, spec)
+	return nil
+}
+
+// normalizeMetadataKey maps the keys used in front matter and descriptions to the ones
+// of the row based metadata table
+func normalizeMetadataKey(key string) string {
+	key = strings.ToLower(strings.TrimSpace(key))
+	switch key {
+	case "author", "author(s)":
+		return "authors"
+	case "id":
+		return "index"
+	}
+	return key
+}
+
+func unquote(value string) string {
+	return strings.Trim(strings.TrimSpace(value), `"'`)
+}
@@ -0,0 +1,102 @@
+package specs
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/canonical/specs-v2.canonical.com/db"
+	"github.com/canonical/specs-v2.canonical.com/google"
+	"github.com/canonical/specs-v2.canonical.com/google/fake"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// testDatabaseEnv names the connection string of a disposable PostgreSQL database the
+// tests recreate the tables of, e.g. the one of docker compose
+const testDatabaseEnv = "TEST_POSTGRESQL_DB_CONNECT_STRING"
+
+// fixturesDir is the spec tree served by the fake Google APIs
+const fixturesDir = "../google/fake/testdata/specs"
+
+// testDB connects to the test database with freshly migrated tables, skipping the test
+// when none is configured
+func testDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv(testDatabaseEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", testDatabaseEnv)
+	}
+
+	conn, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("failed to connect to the test database: %v", err)
+	}
+	if err := db.Rollback(conn); err != nil {
+		t.Fatalf("failed to drop tables: %v", err)
+	}
+	if err := db.Migrate(conn); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := conn.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return conn
+}
+
+// testGoogle starts the fake Google APIs on the fixtures and returns a client of it
+func testGoogle(t *testing.T) (*fake.Server, *google.Google) {
+	t.Helper()
+	server, err := fake.NewServer(testLogger(), fixturesDir)
+	if err != nil {
+		t.Fatalf("failed to start fake google: %v", err)
+	}
+	t.Cleanup(server.Close)
+
+	client, err := google.NewGoogleWithEndpoint(context.Background(), server.Client(), server.URL)
+	if err != nil {
+		t.Fatalf("failed to create google client: %v", err)
+	}
+	return server, client
+}
+
+// testSyncService returns a sync service of the whole fixtures tree
+func testSyncService(t *testing.T, client google.Backend, conn *gorm.DB) *SyncService {
+	t.Helper()
+	return NewSyncService(testLogger(), client, conn, SyncConfig{
+		RootFolderID:  fake.RootFolderID,
+		MaxGoroutines: 4,
+		MaxDepth:      5,
+	})
+}
+
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// specByDocID loads the spec of a Doc, failing the test when there is none
+func specByDocID(t *testing.T, conn *gorm.DB, docID string) db.Spec {
+	t.Helper()
+	var spec db.Spec
+	if err := conn.Where("google_doc_id = ?", docID).First(&spec).Error; err != nil {
+		t.Fatalf("failed to find the spec of %s: %v", docID, err)
+	}
+	return spec
+}
+
+// fileByName returns the ID of the fixture with the given Drive name
+func fileByName(t *testing.T, server *fake.Server, name string) string {
+	t.Helper()
+	for _, file := range server.Files() {
+		if file.Name == name && file.MimeType != google.MimeTypeShortcut {
+			return file.Id
+		}
+	}
+	t.Fatalf("no fixture named %q", name)
+	return ""
+}
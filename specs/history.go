@@ -0,0 +1,205 @@
+package specs
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/canonical/specs-v2.canonical.com/db"
+	"github.com/canonical/specs-v2.canonical.com/google"
+	"github.com/google/uuid"
+	"google.golang.org/api/drive/v3"
+	"gorm.io/gorm"
+)
+
+// HistoryService reconstructs the status history of specs from the revision history of
+// their Google Docs
+type HistoryService struct {
+	Logger       *slog.Logger
+	GoogleClient google.Backend
+	DB           *gorm.DB
+	Config       HistoryConfig
+}
+
+type HistoryConfig struct {
+	// Rebuild discards the recorded history and reads every revision again, instead of
+	// only the revisions saved since the last run
+	Rebuild bool
+}
+
+// SyncAllHistories updates the status history of every spec synced from a Google Doc,
+// the only format with exportable revisions
+func (h *HistoryService) SyncAllHistories(ctx context.Context) error {
+	startTime := time.Now()
+
+	// specs removed by the sync take their history with them
+	if err := h.DB.
+		Where("spec_id NOT IN (?)", h.DB.Model(&db.Spec{}).Select("id")).
+		Delete(&db.SpecStatusHistory{}).Error; err != nil {
+		return fmt.Errorf("failed to delete history of removed specs: %w", err)
+	}
+
+	var specs []*db.Spec
+	if err := h.DB.Where("source_format = ?", SourceFormatGoogleDoc).Find(&specs).Error; err != nil {
+		return fmt.Errorf("failed to query specs: %w", err)
+	}
+
+	var failedCount int
+	for _, spec := range specs {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := h.SyncHistory(ctx, spec); err != nil {
+			h.Logger.Error("failed to sync status history",
+				"spec_id", spec.ID, "doc_id", spec.GoogleDocID, "error", err.Error())
+			failedCount++
+		}
+	}
+
+	h.Logger.Info("status history synchronization completed",
+		"duration", time.Since(startTime).Seconds(),
+		"total", len(specs),
+		"failed", failedCount)
+
+	return nil
+}
+
+// SyncHistory reads the status of a spec in each revision of its Doc saved since the
+// last run, extending the current period while the status is unchanged and starting a
+// new one when it changes. Revisions that cannot be exported or have no metadata table,
+// such as the ones written before the template was filled in, are skipped.
+func (h *HistoryService) SyncHistory(ctx context.Context, spec *db.Spec) error {
+	logger := h.Logger.With("spec_id", spec.ID, "doc_id", spec.GoogleDocID)
+
+	var current *db.SpecStatusHistory
+	if !h.Config.Rebuild {
+		var open db.SpecStatusHistory
+		err := h.DB.Where("spec_id = ? AND ended_at IS NULL", spec.ID).Limit(1).Find(&open).Error
+		if err != nil {
+			return fmt.Errorf("failed to load status history: %w", err)
+		}
+		if open.ID != "" {
+			current = &open
+		}
+	}
+
+	var (
+		changed   []*db.SpecStatusHistory
+		exported  int
+		checkedAt time.Time
+	)
+	// track marks a period to be saved, once
+	track := func(period *db.SpecStatusHistory) {
+		if len(changed) == 0 || changed[len(changed)-1] != period {
+			changed = append(changed, period)
+		}
+	}
+	if current != nil {
+		checkedAt = current.CheckedAt
+	}
+
+	for result := range h.GoogleClient.ListRevisionsChannel(ctx, spec.GoogleDocID) {
+		if result.Err != nil {
+			return fmt.Errorf("failed to list revisions: %w", result.Err)
+		}
+
+		revision := result.Revision
+		modifiedAt, err := time.Parse(time.RFC3339, revision.ModifiedTime)
+		if err != nil || !modifiedAt.After(checkedAt) {
+			continue
+		}
+
+		status, err := h.revisionStatus(ctx, revision)
+		exported++
+		if err != nil {
+			logger.Debug("skipping revision", "revision_id", revision.Id, "error", err.Error())
+			continue
+		}
+
+		if current != nil && strings.EqualFold(current.Status, status) {
+			current.CheckedRevisionID = revision.Id
+			current.CheckedAt = modifiedAt
+		} else {
+			// the ended period may be the open one loaded from the database, which
+			// must be saved closed
+			if current != nil {
+				current.EndedAt = &modifiedAt
+				track(current)
+			}
+			current = &db.SpecStatusHistory{
+				ID:                uuid.NewString(),
+				SpecID:            spec.ID,
+				Status:            status,
+				StartedAt:         modifiedAt,
+				RevisionID:        revision.Id,
+				ChangedBy:         revisionAuthor(revision),
+				CheckedRevisionID: revision.Id,
+				CheckedAt:         modifiedAt,
+			}
+		}
+		track(current)
+	}
+
+	if len(changed) == 0 {
+		return nil
+	}
+
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if h.Config.Rebuild {
+			if err := tx.Where("spec_id = ?", spec.ID).Delete(&db.SpecStatusHistory{}).Error; err != nil {
+				return err
+			}
+		}
+		for _, period := range changed {
+			if err := tx.Save(period).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save status history: %w", err)
+	}
+
+	logger.Debug("status history updated", "exported_revisions", exported, "status", current.Status)
+	return nil
+}
+
+// revisionStatus parses the metadata table of a revision and returns its status
+func (h *HistoryService) revisionStatus(ctx context.Context, revision *drive.Revision) (string, error) {
+	table, err := h.GoogleClient.RevisionMetadataTable(ctx, revision)
+	if err != nil {
+		return "", err
+	}
+
+	var spec db.Spec
+	if err := applyMetadataTable(table, &spec); err != nil {
+		return "", err
+	}
+	if spec.Status == nil || strings.TrimSpace(*spec.Status) == "" {
+		return "", fmt.Errorf("metadata table has no status")
+	}
+	return strings.TrimSpace(*spec.Status), nil
+}
+
+// revisionAuthor returns the name of the user who saved a revision, or their email
+func revisionAuthor(revision *drive.Revision) string {
+	if revision.LastModifyingUser == nil {
+		return ""
+	}
+	if revision.LastModifyingUser.DisplayName != "" {
+		return revision.LastModifyingUser.DisplayName
+	}
+	return revision.LastModifyingUser.EmailAddress
+}
+
+// SyncHistoryByGoogleDocID updates the status history of the spec synced from a Doc
+func (h *HistoryService) SyncHistoryByGoogleDocID(ctx context.Context, googleDocID string) error {
+	var spec *db.Spec
+	if err := h.DB.Where("google_doc_id = ?", googleDocID).First(&spec).Error; err != nil {
+		return fmt.Errorf("failed to find spec with google_doc_id %s: %w", googleDocID, err)
+	}
+	return h.SyncHistory(ctx, spec)
+}
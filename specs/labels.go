@@ -0,0 +1,160 @@
+package specs
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/canonical/specs-v2.canonical.com/db"
+	"github.com/canonical/specs-v2.canonical.com/google"
+	"google.golang.org/api/drive/v3"
+)
+
+// Spec columns that can be set from the fields of a Drive label
+const (
+	LabelColumnTitle    = "title"
+	LabelColumnStatus   = "status"
+	LabelColumnSpecType = "spec_type"
+	LabelColumnTeam     = "team"
+)
+
+var labelColumns = []string{LabelColumnTitle, LabelColumnStatus, LabelColumnSpecType, LabelColumnTeam}
+
+// LabelField identifies a field of a Drive label
+type LabelField struct {
+	LabelID string
+	FieldID string
+}
+
+// LabelMapping maps spec columns to the Drive label fields taking priority over the
+// metadata table
+type LabelMapping map[string]LabelField
+
+// ParseLabelMapping parses comma-separated "<column>=<label ID>/<field ID>" pairs, e.g.
+// "status=0AbC/1dEf,team=0AbC/2gHi". The columns are title, status, spec_type and team.
+func ParseLabelMapping(value string) (LabelMapping, error) {
+	mapping := LabelMapping{}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		column, field, _ := strings.Cut(entry, "=")
+		column = strings.TrimSpace(column)
+		if !slices.Contains(labelColumns, column) {
+			return nil, fmt.Errorf("label mapping %q has unknown column %q", entry, column)
+		}
+		labelID, fieldID, _ := strings.Cut(strings.TrimSpace(field), "/")
+		if labelID == "" || fieldID == "" {
+			return nil, fmt.Errorf("label mapping %q must name a label and a field as <label ID>/<field ID>", entry)
+		}
+		mapping[column] = LabelField{LabelID: labelID, FieldID: fieldID}
+	}
+	return mapping, nil
+}
+
+// LabelIDs returns the IDs of the mapped labels, sorted
+func (m LabelMapping) LabelIDs() []string {
+	var ids []string
+	for _, field := range m {
+		if !slices.Contains(ids, field.LabelID) {
+			ids = append(ids, field.LabelID)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// labelValues returns the values of the mapped label fields applied to a file, by spec
+// column. Selection choices are resolved to their display name and multiple values are
+// joined with commas. Columns whose field is not set are left out.
+func (s *SyncService) labelValues(ctx context.Context, file *drive.File) (map[string]string, error) {
+	values := map[string]string{}
+	for column, field := range s.Config.LabelMapping {
+		fieldValues := google.LabelFieldValues(file, field.LabelID, field.FieldID)
+		for i, value := range fieldValues {
+			name, err := s.labelChoiceName(ctx, field, value)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get choices of label %s: %w", field.LabelID, err)
+			}
+			fieldValues[i] = name
+		}
+		if value := strings.Join(fieldValues, ","); value != "" {
+			values[column] = value
+		}
+	}
+	return values, nil
+}
+
+// labelChoiceName returns the display name of a selection choice of a label field, or
+// the value itself for fields without choices. Label definitions are cached until a
+// choice is missing, e.g. after being added to the label.
+func (s *SyncService) labelChoiceName(ctx context.Context, field LabelField, value string) (string, error) {
+	s.labelsMu.Lock()
+	defer s.labelsMu.Unlock()
+
+	choices, cached := s.labelChoices[field.LabelID]
+	if cached {
+		if _, isSelection := choices[field.FieldID]; !isSelection {
+			return value, nil
+		}
+		if name, ok := choices[field.FieldID][value]; ok {
+			return name, nil
+		}
+	}
+
+	choices, err := s.GoogleClient.LabelChoices(ctx, field.LabelID)
+	if err != nil {
+		return "", err
+	}
+	if s.labelChoices == nil {
+		s.labelChoices = map[string]map[string]map[string]string{}
+	}
+	s.labelChoices[field.LabelID] = choices
+
+	if name, ok := choices[field.FieldID][value]; ok {
+		return name, nil
+	}
+	return value, nil
+}
+
+// applyLabelValues overrides the metadata of a spec with the label values
+func applyLabelValues(spec *db.Spec, values map[string]string) {
+	for column, value := range values {
+		switch column {
+		case LabelColumnTitle:
+			spec.Title = &value
+		case LabelColumnStatus:
+			spec.Status = &value
+		case LabelColumnSpecType:
+			spec.SpecType = &value
+		case LabelColumnTeam:
+			spec.Team = value
+		}
+	}
+}
+
+// labelsChanged reports whether the label values differ from the metadata of a synced
+// spec. Applying a label does not change the modified time of a file.
+func labelsChanged(spec *db.Spec, values map[string]string) bool {
+	for column, value := range values {
+		var current string
+		switch column {
+		case LabelColumnTitle:
+			current = valueOf(spec.Title)
+		case LabelColumnStatus:
+			current = valueOf(spec.Status)
+		case LabelColumnSpecType:
+			current = valueOf(spec.SpecType)
+		case LabelColumnTeam:
+			current = spec.Team
+		}
+		if current != value {
+			return true
+		}
+	}
+	return false
+}
@@ -4,20 +4,42 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"path"
 	"strings"
 	"time"
 
 	"github.com/canonical/specs-v2.canonical.com/db"
+	"github.com/canonical/specs-v2.canonical.com/google"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
+// Designs of the metadata table, recorded as the template version of a spec
+const (
+	TemplateVersionRowBased    = "row"
+	TemplateVersionColumnBased = "column"
+)
+
+// googleAppsMimeTypePrefix is shared by the MIME types of Google Docs, Sheets and Slides
+const googleAppsMimeTypePrefix = "application/vnd.google-apps."
+
 func (s *SyncService) Parse(ctx context.Context, logger *slog.Logger, workerItem *WorkerItem) error {
 	file := workerItem.File
-	parentFolder := workerItem.ParentFolder
 
 	logger.Debug("processing file")
 
-	parts := strings.SplitN(file.File.Name, "-", 2)
+	extractor := s.Extractors.For(file.File.MimeType)
+	if extractor == nil {
+		return fmt.Errorf("no extractor for mime type %s", file.File.MimeType)
+	}
+
+	// uploaded files keep their extension in their name
+	name := file.File.Name
+	if !strings.HasPrefix(file.File.MimeType, googleAppsMimeTypePrefix) {
+		name = strings.TrimSuffix(name, path.Ext(name))
+	}
+
+	parts := strings.SplitN(name, "-", 2)
 	var specId, specTitle string
 	if len(parts) == 2 {
 		specId, specTitle = strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
@@ -35,50 +57,126 @@ func (s *SyncService) Parse(ctx context.Context, logger *slog.Logger, workerItem
 	}
 	googleDocCreatedAt := parsedTime
 
+	// label values take priority over the folder team and the metadata table
+	labels, err := s.labelValues(ctx, file.File)
+	if err != nil {
+		logger.Warn("failed to read labels", "error", err.Error())
+	}
+	team := workerItem.Team
+	if value, ok := labels[LabelColumnTeam]; ok {
+		team = value
+	}
+
 	if !s.Config.ForceSync {
-		var updatedAt time.Time
-		s.DB.Model(&db.Spec{}).Where("id = ?", specId).Pluck("google_doc_updated_at", &updatedAt)
-		if !updatedAt.IsZero() && updatedAt.Equal(googleDocUpdatedAt) {
+		// a Doc moved to another team folder or relabeled keeps its modified time but
+		// must be re-parsed
+		var existing db.Spec
+		s.DB.Select("id", "title", "status", "spec_type", "team", "google_doc_id", "google_doc_updated_at", "source_format", "thumbnail_key").
+			Where("id = ?", specId).Limit(1).Find(&existing)
+		if !existing.GoogleDocUpdatedAt.IsZero() &&
+			existing.GoogleDocUpdatedAt.Equal(googleDocUpdatedAt) &&
+			existing.Team == team &&
+			!labelsChanged(&existing, labels) {
 			logger.Debug("spec hasn't changed since last sync")
 			s.DB.Model(&db.Spec{}).Where("id = ?", specId).Update("synced_at", time.Now())
-			s.SkippedCount++
+			// unchanged specs only cost requests for what was not stored yet, e.g. the
+			// activity of specs synced before comments were imported
+			if !s.hasCommentActivity(specId) {
+				if err := s.syncComments(ctx, specId, file.File.Id); err != nil {
+					logger.Warn("failed to sync comments", "error", err.Error())
+				}
+			}
+			// sharing changes do not update the modified time. Permissions missing from the
+			// listing, as in shared drives, take a request per file and are left to the full
+			// sync.
+			if file.File.Permissions != nil || workerItem.Reconcile {
+				if err := s.syncPermissions(ctx, specId, file.File); err != nil {
+					logger.Warn("failed to sync permissions", "error", err.Error())
+				}
+			}
+			// the thumbnail is only downloaded when its version was not cached
+			if err := s.syncThumbnail(ctx, logger, specId, file.File, existing.ThumbnailKey); err != nil {
+				logger.Warn("failed to sync thumbnail", "error", err.Error())
+			}
+			// terminal specs without a snapshot yet, e.g. after a failed export
+			if existing.Status != nil {
+				if err := s.archiveIfTerminal(ctx, logger, &existing, *existing.Status); err != nil {
+					logger.Warn("failed to archive spec", "error", err.Error())
+				}
+			}
+			s.SkippedCount.Add(1)
 			return nil
 		}
+
+		// outside the full sync, current app properties give the status without an export
+		if existing.ID != "" && existing.Team == team && !labelsChanged(&existing, labels) && !workerItem.Reconcile {
+			refreshed, err := s.refreshFromProperties(ctx, logger, &existing, file.File, googleDocUpdatedAt)
+			if err != nil {
+				return err
+			}
+			if refreshed {
+				s.SkippedCount.Add(1)
+				return nil
+			}
+		}
 	}
 
 	newSpec := db.Spec{
 		ID:                 specId,
 		Title:              &specTitle,
-		Team:               parentFolder.File.Name,
+		Team:               team,
 		GoogleDocID:        file.File.Id,
 		GoogleDocName:      file.File.Name,
 		GoogleDocURL:       file.File.WebViewLink,
 		GoogleDocCreatedAt: googleDocCreatedAt,
 		GoogleDocUpdatedAt: googleDocUpdatedAt,
+		SourceFormat:       extractor.Format(),
 		SyncedAt:           time.Now(),
 	}
 
-	specsMetadataTable, err := s.GoogleClient.DocumentFirstTable(ctx, file.File.Id)
-	if err != nil {
-		return fmt.Errorf("failed to get first table: %w", err)
+	if err := extractor.Extract(ctx, s.GoogleClient, file.File, &newSpec); err != nil {
+		return err
 	}
-	logger.Debug("metadata table", "table", specsMetadataTable)
+	applyLabelValues(&newSpec, labels)
 
-	if len(specsMetadataTable) == 0 {
-		return fmt.Errorf("metadata table is empty")
+	// a PDF or Word copy of a Google Doc must not replace the spec synced from the Doc
+	if newSpec.SourceFormat != SourceFormatGoogleDoc {
+		var count int64
+		s.DB.Model(&db.Spec{}).
+			Where("id = ? AND google_doc_id <> ? AND source_format = ?", newSpec.ID, newSpec.GoogleDocID, SourceFormatGoogleDoc).
+			Count(&count)
+		if count > 0 {
+			logger.Debug("spec already synced from a google doc", "spec_id", newSpec.ID)
+			s.SkippedCount.Add(1)
+			return nil
+		}
 	}
 
-	if isColumnFormat(specsMetadataTable) {
-		parseColumnBasedMetadata(specsMetadataTable, &newSpec)
-	} else {
-		parseRowBasedMetadata(specsMetadataTable, &newSpec)
+	// app properties are not critical to the index either
+	if err := s.syncProperties(ctx, logger, &newSpec, file.File); err != nil {
+		logger.Warn("failed to sync app properties", "error", err.Error())
 	}
 
+	var previous db.Spec
+	s.DB.Select("status", "google_doc_updated_at", "thumbnail_key").Where("id = ?", newSpec.ID).Limit(1).Find(&previous)
+
 	logger.Debug("creating spec", "specs", newSpec)
 	if err := s.DB.Where(db.Spec{ID: newSpec.ID}).Assign(newSpec).FirstOrCreate(&newSpec).Error; err != nil {
 		return fmt.Errorf("failed to upsert spec: %w", err)
 	}
 
+	// a renamed Doc gets a new spec ID, drop the rows synced under its previous name
+	err = s.DB.Transaction(func(tx *gorm.DB) error {
+		previousIDs := tx.Model(&db.Spec{}).Select("id").Where("google_doc_id = ? AND id <> ?", newSpec.GoogleDocID, newSpec.ID)
+		if err := tx.Where("spec_id IN (?)", previousIDs).Delete(&db.Reviewer{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("google_doc_id = ? AND id <> ?", newSpec.GoogleDocID, newSpec.ID).Delete(&db.Spec{}).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to remove previous spec for document: %w", err)
+	}
+
 	logger.Debug("clear old reviewers")
 	if err := s.DB.Where("spec_id = ?", newSpec.ID).Delete(&db.Reviewer{}).Error; err != nil {
 		return fmt.Errorf("failed to clear old reviewers: %w", err)
@@ -91,57 +189,35 @@ func (s *SyncService) Parse(ctx context.Context, logger *slog.Logger, workerItem
 		}
 	}
 
-	return nil
-}
+	if err := s.syncPeople(&newSpec); err != nil {
+		return fmt.Errorf("failed to sync authors and reviewers: %w", err)
+	}
 
-// isColumnFormat checks if the given table has the old specification design or the new one.
-// Old design is row-based, where each row contains a key-value pair. Where the table will look like:
-/*
-[
- 	["authors", "user1@canonical,user2@canonical.com,user3@canonical"],
-	["created", "2021-09-13"],
-	["index", "SN114"]
-]
-*/
-// And the new design is column-based, where the table will look like:
-/*
-[
-	["Index", "PR001", "", ""],
-	["Title", "Specifications - Purpose and Guidance"],
-	["Type", "Author(s)", "Status", "Created"],
-	[
-		"Process",
-		"user1@canonical.com,user2@canonical.com,user3@canonical.com",
-		"Approved",
-		"Apr 22, 2021"
-	],
-	["", "Reviewer(s)", "Status", "Date"],
-	["", "user4@canonical.com", "Approved", "Aug 11, 2023"],
-	["", "user3@canonical.com", "Approved", "Aug 11, 2023"],
-	["", "user2@canonical.com", "Approved", "Aug 11, 2023"],
-	["", "user1@canonical.com", "Approved", "Jul 11, 2023"]
-]
-*/
-// The function expects that the table's third row (index 2) should contain the column headers.
-// The expected column headers are "type", "author(s)", "status", and "created".
-// The function returns true if column headers are found in the, and false otherwise.
-// The function will then check the fifth row (index 4) and following for the reviewers information.
-func isColumnFormat(table [][]string) bool {
-	expectedKeys := []string{"type", "author(s)", "status", "created"}
-	if len(table) < 4 {
-		return false
+	// comments and permissions are not critical to the index, so log the error but do not fail
+	if err := s.syncComments(ctx, newSpec.ID, newSpec.GoogleDocID); err != nil {
+		logger.Warn("failed to sync comments", "error", err.Error())
+	}
+	if err := s.syncPermissions(ctx, newSpec.ID, file.File); err != nil {
+		logger.Warn("failed to sync permissions", "error", err.Error())
+	}
+	if err := s.syncThumbnail(ctx, logger, newSpec.ID, file.File, previous.ThumbnailKey); err != nil {
+		logger.Warn("failed to sync thumbnail", "error", err.Error())
 	}
 
-	foundKeys := 0
-	for _, cell := range table[2] {
-		for _, expected := range expectedKeys {
-			if strings.EqualFold(cell, expected) {
-				foundKeys++
-				break
-			}
-		}
+	if err := s.recordStatusChange(ctx, logger, &newSpec, &previous, file.File); err != nil {
+		logger.Warn("failed to record status change", "error", err.Error())
+	}
+
+	// a failed snapshot is retried by the next sync, as no archive is recorded
+	var previousStatus string
+	if previous.Status != nil {
+		previousStatus = *previous.Status
+	}
+	if err := s.archiveIfTerminal(ctx, logger, &newSpec, previousStatus); err != nil {
+		logger.Warn("failed to archive spec", "error", err.Error())
 	}
-	return foundKeys == len(expectedKeys)
+
+	return nil
 }
 
 func parseRowBasedMetadata(table [][]string, spec *db.Spec) {
@@ -164,9 +240,11 @@ func parseRowBasedMetadata(table [][]string, spec *db.Spec) {
 		case "status":
 			spec.Status = &value
 		case "authors":
-			spec.Authors = parseAuthors([]string{value})
+			setAuthors(spec, parsePeople([]string{value}))
 		case "type":
 			spec.SpecType = &value
+		case "created":
+			setDeclaredCreatedAt(spec, value)
 		}
 	}
 }
@@ -198,9 +276,11 @@ func parseColumnBasedMetadata(table [][]string, spec *db.Spec) {
 		case "status":
 			spec.Status = &value
 		case "author(s)":
-			spec.Authors = parseAuthors([]string{value})
+			setAuthors(spec, parsePeople([]string{value}))
 		case "type":
 			spec.SpecType = &value
+		case "created":
+			setDeclaredCreatedAt(spec, value)
 		}
 	}
 
@@ -209,31 +289,59 @@ func parseColumnBasedMetadata(table [][]string, spec *db.Spec) {
 	}
 
 	reviewerHeaderRow := table[4]
-	reviewerNameIdx := -1
+	reviewerNameIdx, reviewerStatusIdx, reviewerDateIdx := -1, -1, -1
 	for i, col := range reviewerHeaderRow {
-		if strings.ToLower(col) == "reviewer(s)" {
-			reviewerNameIdx = i
-			break
+		switch strings.ToLower(strings.TrimSpace(col)) {
+		case "reviewer(s)":
+			if reviewerNameIdx == -1 {
+				reviewerNameIdx = i
+			}
+		case "status":
+			if reviewerStatusIdx == -1 {
+				reviewerStatusIdx = i
+			}
+		case "date":
+			if reviewerDateIdx == -1 {
+				reviewerDateIdx = i
+			}
 		}
 	}
 	if reviewerNameIdx == -1 {
 		return
 	}
+	// the status follows the name in tables with a renamed status header
+	if reviewerStatusIdx == -1 && reviewerNameIdx+1 < len(reviewerHeaderRow) {
+		reviewerStatusIdx = reviewerNameIdx + 1
+	}
 
 	var reviewers []db.Reviewer
 	for _, row := range table[5:] {
 		if len(row) != len(reviewerHeaderRow) {
 			continue
 		}
-		reviewer := strings.TrimSpace(row[reviewerNameIdx])
-		status := strings.TrimSpace(row[reviewerNameIdx+1])
-		if len(reviewer) > 4 {
-			reviewers = append(reviewers, db.Reviewer{
-				ID:     uuid.NewString(),
-				SpecID: spec.ID,
-				Name:   &reviewer,
-				Status: &status,
-			})
+		var status string
+		if reviewerStatusIdx != -1 {
+			status = strings.TrimSpace(row[reviewerStatusIdx])
+		}
+		var reviewedAt *time.Time
+		if reviewerDateIdx != -1 {
+			if date, ok := parseDeclaredDate(row[reviewerDateIdx]); ok {
+				reviewedAt = &date
+			}
+		}
+		for _, person := range parsePeople([]string{row[reviewerNameIdx]}) {
+			reviewer := db.Reviewer{
+				ID:         uuid.NewString(),
+				SpecID:     spec.ID,
+				Name:       &person.Name,
+				Status:     &status,
+				ReviewedAt: reviewedAt,
+				Position:   len(reviewers),
+			}
+			if person.Email != "" {
+				reviewer.Email = &person.Email
+			}
+			reviewers = append(reviewers, reviewer)
 		}
 	}
 	if len(reviewers) > 0 {
@@ -241,17 +349,43 @@ func parseColumnBasedMetadata(table [][]string, spec *db.Spec) {
 	}
 }
 
-func parseAuthors(values []string) []string {
-	authors := []string{}
+// parsePeople reads the people listed in cells, formatted as google.Person.String does.
+// People without a name are named after their email.
+func parsePeople(values []string) []db.Person {
+	people := []db.Person{}
 	for _, value := range values {
-		for _, author := range strings.FieldsFunc(value, AuthorsSplit) {
-			author = strings.TrimSpace(strings.Split(author, "<")[0])
-			if len(author) > 4 {
-				authors = append(authors, author)
+		for _, field := range strings.FieldsFunc(value, AuthorsSplit) {
+			person := google.ParsePerson(field)
+			if person.Name == "" {
+				person.Name = person.Email
+			}
+			if len(person.Name) > 4 {
+				people = append(people, db.Person{
+					ID:    personID(person),
+					Email: strings.ToLower(person.Email),
+					Name:  person.Name,
+				})
 			}
 		}
 	}
-	return authors
+	return people
+}
+
+// setDeclaredCreatedAt sets the creation date written in the metadata table, if it can
+// be read
+func setDeclaredCreatedAt(spec *db.Spec, value string) {
+	if date, ok := parseDeclaredDate(value); ok {
+		spec.DeclaredCreatedAt = &date
+	}
+}
+
+// setAuthors sets the authors of a spec, keeping their names in Authors
+func setAuthors(spec *db.Spec, people []db.Person) {
+	spec.AuthorPeople = people
+	spec.Authors = make([]string, 0, len(people))
+	for _, person := range people {
+		spec.Authors = append(spec.Authors, person.Name)
+	}
 }
 
 func AuthorsSplit(r rune) bool {
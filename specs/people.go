@@ -0,0 +1,82 @@
+package specs
+
+import (
+	"strings"
+
+	"github.com/canonical/specs-v2.canonical.com/db"
+	"github.com/canonical/specs-v2.canonical.com/google"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// personNamePrefix starts the ID of the people known by name only
+const personNamePrefix = "name:"
+
+// personID identifies a person by their email, or by their name when the metadata
+// table gave none. The same name with and without an email is two people.
+func personID(person google.Person) string {
+	if person.Email != "" {
+		return strings.ToLower(person.Email)
+	}
+	return personNamePrefix + strings.ToLower(strings.TrimSpace(person.Name))
+}
+
+// syncPeople replaces the authors and reviewers of a spec in the spec_authors and
+// spec_reviewers tables, recording the people among them. A person keeps the name
+// they were last listed under.
+func (s *SyncService) syncPeople(spec *db.Spec) error {
+	people := map[string]db.Person{}
+	authors := make([]db.SpecAuthor, 0, len(spec.AuthorPeople))
+	for i, person := range spec.AuthorPeople {
+		if _, ok := people[person.ID]; ok {
+			continue
+		}
+		people[person.ID] = person
+		authors = append(authors, db.SpecAuthor{SpecID: spec.ID, PersonID: person.ID, Position: i})
+	}
+
+	reviewers := make([]db.SpecReviewer, 0, len(spec.Reviewers))
+	reviewed := map[string]bool{}
+	for _, reviewer := range spec.Reviewers {
+		person := google.Person{Name: valueOf(reviewer.Name), Email: valueOf(reviewer.Email)}
+		id := personID(person)
+		if reviewed[id] {
+			continue
+		}
+		reviewed[id] = true
+		if _, ok := people[id]; !ok {
+			people[id] = db.Person{ID: id, Email: strings.ToLower(person.Email), Name: person.Name}
+		}
+		reviewers = append(reviewers, db.SpecReviewer{SpecID: spec.ID, PersonID: id, Status: valueOf(reviewer.Status)})
+	}
+
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		for _, person := range people {
+			err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "id"}},
+				DoUpdates: clause.AssignmentColumns([]string{"name", "updated_at"}),
+			}).Create(&person).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Where("spec_id = ?", spec.ID).Delete(&db.SpecAuthor{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("spec_id = ?", spec.ID).Delete(&db.SpecReviewer{}).Error; err != nil {
+			return err
+		}
+		if len(authors) > 0 {
+			if err := tx.Create(&authors).Error; err != nil {
+				return err
+			}
+		}
+		if len(reviewers) > 0 {
+			if err := tx.Create(&reviewers).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,124 @@
+package specs
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/canonical/specs-v2.canonical.com/db"
+	"github.com/google/uuid"
+	"google.golang.org/api/drive/v3"
+	"gorm.io/gorm"
+)
+
+// Sharing levels of a spec, from the narrowest to the widest audience
+const (
+	// SharingLevelUnknown is used when the permissions of the file cannot be read
+	SharingLevelUnknown = "unknown"
+	// SharingLevelRestricted covers files only shared with people and groups of the company
+	SharingLevelRestricted = "restricted"
+	// SharingLevelDomain covers files shared with a whole company domain
+	SharingLevelDomain = "domain"
+	// SharingLevelExternal covers files shared with people, groups or domains outside the company
+	SharingLevelExternal = "external"
+	// SharingLevelAnyoneWithLink covers files anyone can open with their link
+	SharingLevelAnyoneWithLink = "anyone_with_link"
+	// SharingLevelPublic covers files anyone can find on the web
+	SharingLevelPublic = "public"
+)
+
+var sharingLevelRank = map[string]int{
+	SharingLevelUnknown:        0,
+	SharingLevelRestricted:     1,
+	SharingLevelDomain:         2,
+	SharingLevelExternal:       3,
+	SharingLevelAnyoneWithLink: 4,
+	SharingLevelPublic:         5,
+}
+
+// SharingLevel returns the widest audience the permissions grant access to. Without
+// company domains, only sharing with anyone is considered external.
+func SharingLevel(permissions []*drive.Permission, companyDomains []string) string {
+	level := SharingLevelRestricted
+	for _, permission := range permissions {
+		var permissionLevel string
+		switch {
+		case permission.Type == "anyone" && permission.AllowFileDiscovery:
+			permissionLevel = SharingLevelPublic
+		case permission.Type == "anyone":
+			permissionLevel = SharingLevelAnyoneWithLink
+		case isExternalPermission(permission, companyDomains):
+			permissionLevel = SharingLevelExternal
+		case permission.Type == "domain":
+			permissionLevel = SharingLevelDomain
+		default:
+			continue
+		}
+		if sharingLevelRank[permissionLevel] > sharingLevelRank[level] {
+			level = permissionLevel
+		}
+	}
+	return level
+}
+
+// isExternalPermission reports whether a permission grants access outside the company
+func isExternalPermission(permission *drive.Permission, companyDomains []string) bool {
+	var domain string
+	switch permission.Type {
+	case "anyone":
+		return true
+	case "domain":
+		domain = permission.Domain
+	default:
+		_, domain, _ = strings.Cut(permission.EmailAddress, "@")
+	}
+	if len(companyDomains) == 0 || domain == "" {
+		return false
+	}
+	for _, companyDomain := range companyDomains {
+		if strings.EqualFold(domain, companyDomain) {
+			return false
+		}
+	}
+	return true
+}
+
+// syncPermissions replaces the stored permissions of a spec and its sharing level. The
+// permissions listed with the file are used when Drive returned them, which it only does
+// for files the service account can share and never in shared drives.
+func (s *SyncService) syncPermissions(ctx context.Context, specID string, file *drive.File) error {
+	permissions := file.Permissions
+	if permissions == nil {
+		var err error
+		permissions, err = s.GoogleClient.ListPermissions(ctx, file.Id)
+		if err != nil {
+			return fmt.Errorf("failed to list permissions: %w", err)
+		}
+	}
+
+	level := SharingLevel(permissions, s.Config.CompanyDomains)
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("spec_id = ?", specID).Delete(&db.SpecPermission{}).Error; err != nil {
+			return fmt.Errorf("failed to clear old permissions: %w", err)
+		}
+		for _, permission := range permissions {
+			row := db.SpecPermission{
+				ID:          uuid.NewString(),
+				SpecID:      specID,
+				Type:        permission.Type,
+				Role:        permission.Role,
+				Email:       strings.ToLower(permission.EmailAddress),
+				Domain:      permission.Domain,
+				DisplayName: permission.DisplayName,
+				External:    isExternalPermission(permission, s.Config.CompanyDomains),
+			}
+			if err := tx.Create(&row).Error; err != nil {
+				return fmt.Errorf("failed to insert permission: %w", err)
+			}
+		}
+		if err := tx.Model(&db.Spec{}).Where("id = ?", specID).Update("sharing_level", level).Error; err != nil {
+			return fmt.Errorf("failed to update sharing level: %w", err)
+		}
+		return nil
+	})
+}
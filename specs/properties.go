@@ -0,0 +1,251 @@
+package specs
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"strings"
+	"time"
+
+	"github.com/canonical/specs-v2.canonical.com/db"
+	"github.com/canonical/specs-v2.canonical.com/google"
+	"github.com/google/uuid"
+	"google.golang.org/api/drive/v3"
+	"gorm.io/gorm"
+)
+
+// Keys of the app properties written on spec files. Drive limits each key and value
+// pair to 124 bytes, and app properties are private to the OAuth client writing them.
+const (
+	AppPropertySpecID          = "spec_id"
+	AppPropertyStatus          = "status"
+	AppPropertyType            = "type"
+	AppPropertyTemplateVersion = "template_version"
+	AppPropertyLastAction      = "last_action"
+	AppPropertyLastActionAt    = "last_action_at"
+	AppPropertyCleanupID       = "cleanup_id"
+	// AppPropertyModifiedTime is the modified time of the file the properties were
+	// written for, they describe the current content as long as it is unchanged
+	AppPropertyModifiedTime = "modified_time"
+)
+
+// Automation actions recorded in the last_action app property
+const (
+	ActionReject = "reject"
+)
+
+// SpecProperties is the spec metadata kept in the app properties of its file, so the
+// status can be checked without exporting the Doc and automation state travels with it
+type SpecProperties struct {
+	SpecID          string
+	Status          string
+	Type            string
+	TemplateVersion string
+	LastAction      string
+	LastActionAt    string
+	CleanupID       string
+	ModifiedTime    string
+}
+
+// PropertiesOf reads the spec properties of a file, empty when none were written
+func PropertiesOf(file *drive.File) SpecProperties {
+	props := file.AppProperties
+	return SpecProperties{
+		SpecID:          props[AppPropertySpecID],
+		Status:          props[AppPropertyStatus],
+		Type:            props[AppPropertyType],
+		TemplateVersion: props[AppPropertyTemplateVersion],
+		LastAction:      props[AppPropertyLastAction],
+		LastActionAt:    props[AppPropertyLastActionAt],
+		CleanupID:       props[AppPropertyCleanupID],
+		ModifiedTime:    props[AppPropertyModifiedTime],
+	}
+}
+
+// Map returns the properties to write, leaving out empty values
+func (p SpecProperties) Map() map[string]string {
+	props := map[string]string{
+		AppPropertySpecID:          p.SpecID,
+		AppPropertyStatus:          p.Status,
+		AppPropertyType:            p.Type,
+		AppPropertyTemplateVersion: p.TemplateVersion,
+		AppPropertyLastAction:      p.LastAction,
+		AppPropertyLastActionAt:    p.LastActionAt,
+		AppPropertyCleanupID:       p.CleanupID,
+		AppPropertyModifiedTime:    p.ModifiedTime,
+	}
+	maps.DeleteFunc(props, func(_, value string) bool { return value == "" })
+	return props
+}
+
+// Current reports whether the properties were written for the current content of the
+// file, i.e. it was not edited since
+func (p SpecProperties) Current(file *drive.File) bool {
+	return p.ModifiedTime != "" && p.ModifiedTime == file.ModifiedTime
+}
+
+// PropertyConflict is a value of the metadata table differing from the app property
+// recorded on the file
+type PropertyConflict struct {
+	Key      string
+	Table    string
+	Property string
+}
+
+// reconcileProperties returns the properties of a file updated with the metadata read
+// from its table, and the values the two disagreed on. The table is what people edit,
+// so it wins over the properties, while the automation state only lives in the latter.
+func reconcileProperties(props SpecProperties, spec *db.Spec) (SpecProperties, []PropertyConflict) {
+	var conflicts []PropertyConflict
+	reconcile := func(key string, property *string, table string) {
+		if *property != "" && !strings.EqualFold(*property, table) {
+			conflicts = append(conflicts, PropertyConflict{Key: key, Table: table, Property: *property})
+		}
+		*property = table
+	}
+
+	reconcile(AppPropertySpecID, &props.SpecID, spec.ID)
+	reconcile(AppPropertyStatus, &props.Status, strings.TrimSpace(valueOf(spec.Status)))
+	reconcile(AppPropertyType, &props.Type, strings.TrimSpace(valueOf(spec.SpecType)))
+	reconcile(AppPropertyTemplateVersion, &props.TemplateVersion, spec.TemplateVersion)
+	return props, conflicts
+}
+
+// syncProperties reconciles the app properties of a spec file with the metadata read
+// from its table and labels, recording the conflicts in spec_property_conflicts. The
+// properties are written back when they changed and Config.WriteAppProperties is set,
+// keeping the modified time of the file.
+func (s *SyncService) syncProperties(ctx context.Context, logger *slog.Logger, spec *db.Spec, file *drive.File) error {
+	previous := PropertiesOf(file)
+	props, conflicts := reconcileProperties(previous, spec)
+	for _, conflict := range conflicts {
+		logger.Warn("metadata table conflicts with app properties",
+			"key", conflict.Key,
+			"table", conflict.Table,
+			"property", conflict.Property,
+			"last_action", previous.LastAction,
+		)
+	}
+	if len(conflicts) > 0 {
+		s.ConflictCount.Add(1)
+	}
+	if err := s.recordConflicts(spec.ID, previous.LastAction, conflicts); err != nil {
+		return err
+	}
+
+	if !s.Config.WriteAppProperties {
+		return nil
+	}
+	props.ModifiedTime = file.ModifiedTime
+	if maps.Equal(props.Map(), previous.Map()) {
+		return nil
+	}
+	if _, err := s.GoogleClient.UpdateAppProperties(ctx, file.Id, props.Map(), file.ModifiedTime); err != nil {
+		return fmt.Errorf("failed to update app properties: %w", err)
+	}
+	return nil
+}
+
+// recordConflicts replaces the property conflicts stored for a spec, clearing them once
+// the table and the app properties agree again
+func (s *SyncService) recordConflicts(specID, lastAction string, conflicts []PropertyConflict) error {
+	return s.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("spec_id = ?", specID).Delete(&db.SpecPropertyConflict{}).Error; err != nil {
+			return fmt.Errorf("failed to clear property conflicts: %w", err)
+		}
+		if len(conflicts) == 0 {
+			return nil
+		}
+		rows := make([]db.SpecPropertyConflict, len(conflicts))
+		for i, conflict := range conflicts {
+			rows[i] = db.SpecPropertyConflict{
+				ID:            uuid.NewString(),
+				SpecID:        specID,
+				Key:           conflict.Key,
+				TableValue:    conflict.Table,
+				PropertyValue: conflict.Property,
+				LastAction:    lastAction,
+				DetectedAt:    time.Now(),
+			}
+		}
+		if err := tx.Create(&rows).Error; err != nil {
+			return fmt.Errorf("failed to record property conflicts: %w", err)
+		}
+		return nil
+	})
+}
+
+// refreshFromProperties updates the status and type of an indexed spec from the app
+// properties of its file when they were written for its current content, e.g. by the
+// reject service after editing the Doc, sparing the export. The rest of the metadata may
+// be outdated, so the stored modified time is left as it was for the full sync to
+// re-parse the Doc. It reports whether the spec was refreshed.
+func (s *SyncService) refreshFromProperties(
+	ctx context.Context,
+	logger *slog.Logger,
+	existing *db.Spec,
+	file *drive.File,
+	modifiedAt time.Time,
+) (bool, error) {
+	props := PropertiesOf(file)
+	if !props.Current(file) || props.SpecID != existing.ID || props.Status == "" {
+		return false, nil
+	}
+
+	updated := *existing
+	updated.Status = &props.Status
+	updated.GoogleDocUpdatedAt = modifiedAt
+	updates := map[string]any{"status": props.Status, "synced_at": time.Now()}
+	if props.Type != "" {
+		updated.SpecType = &props.Type
+		updates["spec_type"] = props.Type
+	}
+	if props.TemplateVersion != "" {
+		updates["template_version"] = props.TemplateVersion
+	}
+	if err := s.DB.Model(&db.Spec{}).Where("id = ?", existing.ID).Updates(updates).Error; err != nil {
+		return false, fmt.Errorf("failed to update spec: %w", err)
+	}
+	logger.Debug("spec refreshed from app properties", "status", props.Status, "last_action", props.LastAction)
+
+	if err := s.recordStatusChange(ctx, logger, &updated, existing, file); err != nil {
+		logger.Warn("failed to record status change", "error", err.Error())
+	}
+	if err := s.archiveIfTerminal(ctx, logger, &updated, valueOf(existing.Status)); err != nil {
+		logger.Warn("failed to archive spec", "error", err.Error())
+	}
+	return true, nil
+}
+
+// recordAction writes the status set by an automation action to the app properties of
+// a Doc it just edited, marking them current for the new content
+func recordAction(
+	ctx context.Context,
+	client google.Backend,
+	docID, action, status, cleanupID string,
+) error {
+	file, err := client.GetFile(ctx, docID)
+	if err != nil {
+		return fmt.Errorf("failed to get file: %w", err)
+	}
+
+	props := PropertiesOf(file)
+	props.Status = status
+	props.LastAction = action
+	props.LastActionAt = time.Now().UTC().Format(time.RFC3339)
+	props.CleanupID = cleanupID
+	props.ModifiedTime = file.ModifiedTime
+
+	if _, err := client.UpdateAppProperties(ctx, docID, props.Map(), file.ModifiedTime); err != nil {
+		return fmt.Errorf("failed to update app properties: %w", err)
+	}
+	return nil
+}
+
+func valueOf(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -16,9 +16,12 @@ import (
 // RejectService handles the rejection of old specification documents
 type RejectService struct {
 	Logger       *slog.Logger
-	GoogleClient *google.Google
-	DB           *gorm.DB
-	Config       RejectConfig
+	GoogleClient google.Backend
+	// TeamClients edit the Docs of a team, e.g. as a team account impersonated through
+	// domain-wide delegation. GoogleClient is used for teams without a client.
+	TeamClients map[string]google.Backend
+	DB          *gorm.DB
+	Config      RejectConfig
 
 	failedCount   int
 	rejectedCount int
@@ -29,15 +32,21 @@ type RejectConfig struct {
 	DryRun bool
 	// RejectThreshold defines how old a spec must be to be considered stale
 	RejectThreshold time.Duration
+	// Actor is the email of the account editing the Docs, recorded with the status
+	// changes, and TeamActors the one of each team with its own client
+	Actor      string
+	TeamActors map[string]string
 }
 
 // findStaleSpecs identifies specifications that:
 //   - Have "Drafting" or "Braindump" status
 //   - Have not been updated in the configured threshold period.
+//   - Are Google Docs, the only format the service can edit.
 func (r *RejectService) findStaleSpecs() ([]*db.Spec, error) {
 	var specs []*db.Spec
 	err := r.DB.
 		Where("LOWER(status) IN ?", []string{"drafting", "braindump"}).
+		Where("source_format = ?", SourceFormatGoogleDoc).
 		Where("google_doc_updated_at < ?", time.Now().Add(r.Config.RejectThreshold)).
 		Find(&specs).Error
 
@@ -96,23 +105,33 @@ func (r *RejectService) RejectSpec(
 	cleanupID string,
 ) error {
 	logger := r.Logger.With("spec_id", spec.ID, "doc_id", spec.GoogleDocID)
+	client := r.clientFor(spec.Team)
 
 	if r.Config.DryRun {
 		logger.Info("would reject spec (dry run)")
 		return nil
 	}
 
-	// Find the status cell coordinates
-	coords, err := r.findStatusCell(ctx, spec.GoogleDocID)
+	// App properties written for the current content answer without reading the Doc
+	file, err := client.GetFile(ctx, spec.GoogleDocID)
+	if err != nil {
+		return fmt.Errorf("failed to get file: %v", err)
+	}
+	if props := PropertiesOf(file); props.Current(file) && !isDraftStatus(props.Status) {
+		return fmt.Errorf("document is not a draft/braindump")
+	}
+
+	// Find the status cell
+	cell, err := r.findStatusCell(ctx, client, spec.GoogleDocID)
 	if err != nil {
 		return fmt.Errorf("failed to find status cell: %v", err)
 	}
-	if coords == nil {
+	if cell == nil {
 		return fmt.Errorf("document is not a draft/braindump")
 	}
 
 	// Update the Google Doc
-	if err := r.updateDocumentStatus(ctx, spec.GoogleDocID, coords, "Rejected"); err != nil {
+	if err := r.updateDocumentStatus(ctx, client, spec.GoogleDocID, cell, "Rejected"); err != nil {
 		return fmt.Errorf("failed to update document: %v", err)
 	}
 
@@ -120,7 +139,25 @@ func (r *RejectService) RejectSpec(
 		"status":    "Rejected",
 		"synced_at": time.Now(),
 	}
-	if err := r.DB.Model(spec).Where("id = ?", spec.ID).Updates(updateData).Error; err != nil {
+	change := db.SpecStatusChange{
+		ID:         uuid.NewString(),
+		SpecID:     spec.ID,
+		ToStatus:   "Rejected",
+		ChangedAt:  time.Now(),
+		Actor:      r.actorFor(spec.Team),
+		Automated:  true,
+		DetectedAt: time.Now(),
+	}
+	if spec.Status != nil {
+		change.FromStatus = strings.TrimSpace(*spec.Status)
+	}
+	err = r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(spec).Where("id = ?", spec.ID).Updates(updateData).Error; err != nil {
+			return err
+		}
+		return tx.Create(&change).Error
+	})
+	if err != nil {
 		return fmt.Errorf("failed to update spec status in database: %v", err)
 	}
 
@@ -128,60 +165,69 @@ func (r *RejectService) RejectSpec(
 
 	// Add rejection notice to the document
 	// Rejection notice is not critical, so log error but do not fail
-	if err = r.addRejectionNotice(ctx, spec.GoogleDocID, cleanupID); err != nil {
-		if err = r.addFallbackRejectionNotice(ctx, spec.GoogleDocID, cleanupID); err != nil {
+	if err = r.addRejectionNotice(ctx, client, spec.GoogleDocID, cleanupID); err != nil {
+		if err = r.addFallbackRejectionNotice(ctx, client, spec.GoogleDocID, cell.TabID, cleanupID); err != nil {
 			logger.Error("failed to add rejection notice", "error", err.Error())
 		}
 	}
 
+	// Recorded once the Doc is no longer edited, so the properties describe its final
+	// content. This is not critical either, the next sync reconciles the status.
+	if err := recordAction(ctx, client, spec.GoogleDocID, ActionReject, "Rejected", cleanupID); err != nil {
+		logger.Error("failed to record rejection in app properties", "error", err.Error())
+	}
+
 	return nil
 }
 
-// findStatusCell locates the position of a spec status cell in a Google Doc
+// clientFor returns the client editing the Docs of a team
+func (r *RejectService) clientFor(team string) google.Backend {
+	if client, ok := r.TeamClients[team]; ok {
+		return client
+	}
+	return r.GoogleClient
+}
+
+// actorFor returns the email of the account editing the Docs of a team
+func (r *RejectService) actorFor(team string) string {
+	if actor, ok := r.Config.TeamActors[team]; ok {
+		return actor
+	}
+	return r.Config.Actor
+}
+
+// findStatusCell locates the spec status cell in a Google Doc. It returns nil if the
+// spec is not a draft or a braindump.
 func (r *RejectService) findStatusCell(
 	ctx context.Context,
+	client google.Backend,
 	docID string,
-) (*cellCoordinates, error) {
-	table, err := r.GoogleClient.DocumentFirstTable(ctx, docID)
-	if err != nil || len(table) == 0 {
+) (*google.TableCell, error) {
+	table, err := client.DocumentTable(ctx, docID)
+	if err != nil {
 		return nil, fmt.Errorf("metadata not found or malformed: %v", err)
 	}
 
-	// Find the status cell coordinates using table format detection
-	var coords *cellCoordinates
-	if isColumnFormat(table) {
-		coords = findStatusInColumnFormat(table)
-	} else {
-		coords = findStatusInRowFormat(table)
-	}
+	r.Logger.Debug("found metadata table", "doc_id", docID, "position", table.Position)
 
-	return coords, nil
-}
-
-// findStatusInColumnFormat searches for status in column-based table format
-func findStatusInColumnFormat(table [][]string) *cellCoordinates {
-	if len(table) < 4 || len(table[3]) < 3 {
-		return nil
+	// Find the status cell with the parser of the template the table matches
+	values := table.Strings()
+	parser := MetadataParsers.Detect(values)
+	if parser == nil {
+		return nil, nil
 	}
-
-	if status := strings.ToLower(strings.TrimSpace(table[3][2])); status == "drafting" || status == "braindump" {
-		return &cellCoordinates{Row: 3, Col: 2}
+	row, col, ok := parser.StatusCell(values)
+	if !ok || !isDraftStatus(values[row][col]) {
+		return nil, nil
 	}
 
-	return nil
+	return table.Cell(row, col)
 }
 
-// findStatusInRowFormat searches for status in row-based table format
-func findStatusInRowFormat(table [][]string) *cellCoordinates {
-	if len(table) < 3 || len(table[2]) < 2 {
-		return nil
-	}
-
-	if status := strings.ToLower(strings.TrimSpace(table[2][1])); status == "drafting" || status == "braindump" {
-		return &cellCoordinates{Row: 2, Col: 1}
-	}
-
-	return nil
+// isDraftStatus reports whether a status is one of the stale specs rejected
+func isDraftStatus(status string) bool {
+	status = strings.ToLower(strings.TrimSpace(status))
+	return status == "drafting" || status == "braindump"
 }
 
 // RejectSpecByGoogleDocID finds a spec by its Google Doc ID and rejects it
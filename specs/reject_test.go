@@ -0,0 +1,90 @@
+package specs
+
+import (
+	"context"
+	"testing"
+
+	"github.com/canonical/specs-v2.canonical.com/db"
+	"google.golang.org/api/docs/v1"
+)
+
+func TestRejectSpec(t *testing.T) {
+	conn := testDB(t)
+	server, client := testGoogle(t)
+	ctx := context.Background()
+
+	if err := testSyncService(t, client, conn).SyncSpecs(ctx); err != nil {
+		t.Fatalf("SyncSpecs() error = %v", err)
+	}
+	docID := fileByName(t, server, "EN002 - Stale drafting spec")
+	spec := specByDocID(t, conn, docID)
+
+	reject := &RejectService{
+		Logger:       testLogger(),
+		GoogleClient: client,
+		DB:           conn,
+		Config:       RejectConfig{Actor: "specs-bot@canonical.com"},
+	}
+	if err := reject.RejectSpec(ctx, &spec, "cleanup"); err != nil {
+		t.Fatalf("RejectSpec() error = %v", err)
+	}
+
+	rejected := specByDocID(t, conn, docID)
+	if valueOf(rejected.Status) != "Rejected" {
+		t.Errorf("status = %q, want Rejected", valueOf(rejected.Status))
+	}
+
+	var changes []db.SpecStatusChange
+	conn.Where("spec_id = ?", spec.ID).Find(&changes)
+	if len(changes) != 1 {
+		t.Fatalf("status changes = %d, want 1", len(changes))
+	}
+	if change := changes[0]; change.FromStatus != "Drafting" || change.ToStatus != "Rejected" ||
+		!change.Automated || change.Actor != "specs-bot@canonical.com" {
+		t.Errorf("status change = %+v, want an automated change from Drafting to Rejected", change)
+	}
+
+	if !insertsText(server.Updates(docID), "Rejected") {
+		t.Errorf("the status cell of the Doc was not set to Rejected")
+	}
+
+	file, err := client.GetFile(ctx, docID)
+	if err != nil {
+		t.Fatalf("GetFile() error = %v", err)
+	}
+	if props := PropertiesOf(file); props.Status != "Rejected" {
+		t.Errorf("status app property = %q, want Rejected", props.Status)
+	}
+}
+
+func TestRejectSpecLeavesOtherStatuses(t *testing.T) {
+	conn := testDB(t)
+	server, client := testGoogle(t)
+	ctx := context.Background()
+
+	if err := testSyncService(t, client, conn).SyncSpecs(ctx); err != nil {
+		t.Fatalf("SyncSpecs() error = %v", err)
+	}
+	spec := specByDocID(t, conn, "en001")
+
+	reject := &RejectService{Logger: testLogger(), GoogleClient: client, DB: conn}
+	if err := reject.RejectSpec(ctx, &spec, "cleanup"); err == nil {
+		t.Fatalf("RejectSpec() rejected a %s spec", valueOf(spec.Status))
+	}
+	if updates := server.Updates("en001"); len(updates) != 0 {
+		t.Errorf("the Doc was edited: %d updates", len(updates))
+	}
+	if unchanged := specByDocID(t, conn, "en001"); valueOf(unchanged.Status) != valueOf(spec.Status) {
+		t.Errorf("status = %q, want %q", valueOf(unchanged.Status), valueOf(spec.Status))
+	}
+}
+
+// insertsText reports whether a batch update inserts the text, e.g. in a table cell
+func insertsText(requests []*docs.Request, text string) bool {
+	for _, request := range requests {
+		if request.InsertText != nil && request.InsertText.Text == text {
+			return true
+		}
+	}
+	return false
+}
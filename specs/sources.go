@@ -0,0 +1,172 @@
+package specs
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/canonical/specs-v2.canonical.com/google"
+	"google.golang.org/api/drive/v3"
+)
+
+// Team naming rules of a sync source
+const (
+	// TeamRuleTopFolder names the team after the top-level folder of a Doc, Docs at the
+	// root of the source taking the source Team
+	TeamRuleTopFolder = "top-folder"
+	// TeamRuleDrive gives every Doc the source Team, or the shared drive name if unset
+	TeamRuleDrive = "drive"
+	// TeamRuleFixed gives every Doc the source Team
+	TeamRuleFixed = "fixed"
+)
+
+// myDriveFolderID is the alias of the root folder of the user's My Drive
+const myDriveFolderID = "root"
+
+// SyncSource is a place specs are synced from: a folder in the user corpus, a whole
+// shared drive, or a folder in a shared drive
+type SyncSource struct {
+	// DriveID is the shared drive to enumerate, empty for the user corpus
+	DriveID string
+	// FolderID is the folder to start from. It defaults to the root of the shared drive,
+	// or to My Drive in the user corpus.
+	FolderID string
+	// TeamRule is one of the TeamRule constants, TeamRuleTopFolder when empty
+	TeamRule string
+	// Team is used as described by TeamRule, SyncConfig.RootTeam is used when unset
+	Team string
+}
+
+// RootID returns the ID of the folder the source is traversed from
+func (src SyncSource) RootID() string {
+	switch {
+	case src.FolderID != "":
+		return src.FolderID
+	case src.DriveID != "":
+		return src.DriveID
+	default:
+		return myDriveFolderID
+	}
+}
+
+func (src SyncSource) String() string {
+	if src.DriveID == "" {
+		return "user:" + src.RootID()
+	}
+	if src.FolderID == "" {
+		return "drive:" + src.DriveID
+	}
+	return "drive:" + src.DriveID + "/" + src.FolderID
+}
+
+// ParseSyncSources parses a comma separated list of sources, each written as
+//
+//	user[:<folder ID>][@<rule>[=<team>]]
+//	drive:<drive ID>[/<folder ID>][@<rule>[=<team>]]
+//
+// where rule is one of top-folder, drive or fixed, e.g.
+// "user:1AbC,drive:0XyZ@drive,drive:0XyZ/1Def@fixed=Design".
+func ParseSyncSources(value string) ([]SyncSource, error) {
+	var sources []SyncSource
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		location, rule, _ := strings.Cut(entry, "@")
+		corpus, id, _ := strings.Cut(location, ":")
+
+		var source SyncSource
+		switch corpus {
+		case "user":
+			source.FolderID = id
+		case "drive":
+			if id == "" {
+				return nil, fmt.Errorf("sync source %q has no drive ID", entry)
+			}
+			source.DriveID, source.FolderID, _ = strings.Cut(id, "/")
+		default:
+			return nil, fmt.Errorf("sync source %q has unknown corpus %q", entry, corpus)
+		}
+
+		if rule != "" {
+			source.TeamRule, source.Team, _ = strings.Cut(rule, "=")
+			switch source.TeamRule {
+			case TeamRuleTopFolder, TeamRuleDrive:
+			case TeamRuleFixed:
+				if source.Team == "" {
+					return nil, fmt.Errorf("sync source %q uses the fixed team rule without a team", entry)
+				}
+			default:
+				return nil, fmt.Errorf("sync source %q has unknown team rule %q", entry, source.TeamRule)
+			}
+		}
+
+		sources = append(sources, source)
+	}
+	return sources, nil
+}
+
+// sources returns the configured sources, defaulting to the root folder
+func (s *SyncService) sources() []SyncSource {
+	if len(s.Config.Sources) > 0 {
+		return s.Config.Sources
+	}
+	return []SyncSource{{FolderID: s.Config.RootFolderID, Team: s.Config.RootTeam}}
+}
+
+// sourceAt returns the source traversed from the given folder, if any
+func (s *SyncService) sourceAt(folderID string) (SyncSource, bool) {
+	for _, source := range s.sources() {
+		if source.RootID() == folderID {
+			return source, true
+		}
+	}
+	return SyncSource{}, false
+}
+
+// rootFolderItem returns the root folder of a source with the team of the Docs placed
+// directly in it
+func (s *SyncService) rootFolderItem(ctx context.Context, source SyncSource) (folderItem, error) {
+	rootTeam := source.Team
+	if rootTeam == "" {
+		rootTeam = s.Config.RootTeam
+	}
+
+	if team, ok := s.Config.TeamMapping[source.RootID()]; ok && source.TeamRule != TeamRuleFixed {
+		rootTeam = team
+	} else if source.TeamRule == TeamRuleDrive && source.Team == "" && source.DriveID != "" {
+		sharedDrive, err := s.GoogleClient.GetDrive(ctx, source.DriveID)
+		if err != nil {
+			return folderItem{}, fmt.Errorf("failed to get shared drive: %w", err)
+		}
+		rootTeam = sharedDrive.Name
+	}
+
+	return folderItem{
+		Folder: google.FileResult{File: &drive.File{Id: source.RootID(), Name: rootTeam}},
+		Team:   rootTeam,
+		Source: source,
+	}, nil
+}
+
+// folderTeam resolves the team of a subfolder following the rule of its source. With the
+// top-folder rule a mapped folder ID or name wins, top-level folders are named after their
+// team, and deeper folders inherit their parent's team. Other rules keep the source team,
+// only the drive rule honouring the team mapping.
+func (s *SyncService) folderTeam(folder *drive.File, parent folderItem) string {
+	if parent.Source.TeamRule == TeamRuleFixed {
+		return parent.Team
+	}
+	if team, ok := s.Config.TeamMapping[folder.Id]; ok {
+		return team
+	}
+	if team, ok := s.Config.TeamMapping[folder.Name]; ok {
+		return team
+	}
+	if parent.Depth == 0 && parent.Source.TeamRule != TeamRuleDrive {
+		return folder.Name
+	}
+	return parent.Team
+}
@@ -2,45 +2,128 @@ package specs
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/canonical/specs-v2.canonical.com/db"
 	"github.com/canonical/specs-v2.canonical.com/google"
+	"google.golang.org/api/drive/v3"
 	"gorm.io/gorm"
 )
 
 // SyncService handles the synchronization of specification documents from Google Drive
 type SyncService struct {
 	Logger       *slog.Logger
-	GoogleClient *google.Google
+	GoogleClient google.Backend
 	DB           *gorm.DB
 	Config       SyncConfig
+	// Extractors reads the metadata of each synced MIME type, Config.Extractors or Google Docs only
+	Extractors ExtractorRegistry
+	// Archive stores PDF snapshots of specs reaching a terminal status, archiving is
+	// disabled when nil
+	Archive ObjectStore
+	// Thumbnails caches the Drive thumbnails of spec files, disabled when nil
+	Thumbnails ObjectStore
 
-	FailedCount  int
-	SkippedCount int
+	// The counters of the last sync, updated by concurrent workers
+	FailedCount  atomic.Int64
+	SkippedCount atomic.Int64
+	// ConflictCount is the number of specs whose metadata table disagreed with the app
+	// properties of their file
+	ConflictCount atomic.Int64
+
+	// labelChoices caches the display names of the selection choices of the mapped
+	// labels, by label ID, field ID and choice ID
+	labelChoices map[string]map[string]map[string]string
+	labelsMu     sync.Mutex
 }
 
 type SyncConfig struct {
+	// RootFolderID is the folder synced when no Sources are configured
 	RootFolderID  string
 	MaxGoroutines int
 	// ForceSync forces the synchronization of all specs without checking the last updated time
 	ForceSync bool
+	// MaxDepth limits how many folder levels below the root folder are traversed.
+	// Docs at the root are always synced, a depth of 1 only includes the team folders.
+	MaxDepth int
+	// TeamMapping maps a folder ID or name to the team owning the specs below it.
+	// Folders that are not mapped inherit the team of their top-level folder.
+	TeamMapping map[string]string
+	// RootTeam is the team of Docs placed directly in the root folder, unless the
+	// root folder ID is present in TeamMapping
+	RootTeam string
+	// Sources lists the folders and shared drives to sync, each with its own team rule.
+	// Docs found in several sources are synced from the first one.
+	Sources []SyncSource
+	// Extractors selects the file formats synced and how their metadata is read,
+	// Google Docs only when nil
+	Extractors ExtractorRegistry
+	// Incremental enables SyncChanges, which only re-parses the Docs reported by the
+	// Drive Changes API. Full syncs then record the page token to resume from.
+	Incremental bool
+	// FullSyncInterval is how often SyncChanges falls back to a full reconciliation
+	FullSyncInterval time.Duration
+	// Watch configures the Drive push notification channels
+	Watch WatchConfig
+	// CompanyDomains are the email domains of the company. Sharing with any other
+	// domain is reported as external.
+	CompanyDomains []string
+	// ArchivePrefix is prepended to the keys of the PDF snapshots in the archive bucket
+	ArchivePrefix string
+	// ThumbnailPrefix is prepended to the keys of the cached thumbnails
+	ThumbnailPrefix string
+	// LabelMapping sets spec columns from the fields of the Drive labels applied to each
+	// file, taking priority over the metadata table. The Google client must include the
+	// mapped labels in the files it lists.
+	LabelMapping LabelMapping
+	// WriteAppProperties records the metadata read from the table in the app properties
+	// of each re-parsed file, which needs a Drive scope allowing metadata writes
+	WriteAppProperties bool
+	// AttributeStatusChanges looks up the author of each status change with the Drive
+	// Activity API, which needs the drive.activity.readonly scope
+	AttributeStatusChanges bool
+	// AutomationEmails are the accounts of the services editing Docs. Status changes
+	// made by them are recorded as automated.
+	AutomationEmails []string
 }
 
 type WorkerItem struct {
 	File         google.FileResult
 	ParentFolder google.FileResult
+	// Team is resolved from the top-level folder or the team mapping
+	Team string
+	// Reconcile is set by the full sync, which also refreshes what the modified time of
+	// unchanged specs does not cover at the cost of extra requests
+	Reconcile bool
+	// Done is called with the result of parsing the item, when set
+	Done func(err error)
+}
+
+// folderItem is a folder waiting to be traversed
+type folderItem struct {
+	Folder google.FileResult
+	Team   string
+	Depth  int
+	Source SyncSource
 }
 
 // NewSyncService creates a new specification synchronization service
-func NewSyncService(logger *slog.Logger, driveClient *google.Google, db *gorm.DB, config SyncConfig) *SyncService {
+func NewSyncService(logger *slog.Logger, driveClient google.Backend, db *gorm.DB, config SyncConfig) *SyncService {
+	extractors := config.Extractors
+	if extractors == nil {
+		extractors, _ = NewExtractorRegistry()
+	}
+
 	return &SyncService{
 		Logger:       logger.With("component", "specs_sync"),
 		GoogleClient: driveClient,
 		DB:           db,
 		Config:       config,
+		Extractors:   extractors,
 	}
 }
 
@@ -60,7 +143,10 @@ func (s *SyncService) worker(ctx context.Context, id int, items <-chan *WorkerIt
 			err := s.Parse(ctx, logger, item)
 			if err != nil {
 				logger.Error("failed to parse file", "error", err.Error())
-				s.FailedCount++
+				s.FailedCount.Add(1)
+			}
+			if item.Done != nil {
+				item.Done(err)
 			}
 		}
 	}
@@ -69,13 +155,98 @@ func (s *SyncService) worker(ctx context.Context, id int, items <-chan *WorkerIt
 // SyncSpecs synchronizes the specification documents from Google Drive
 func (s *SyncService) SyncSpecs(ctx context.Context) error {
 	s.Logger.Info("starting specs synchronization",
-		"root_folder_id", s.Config.RootFolderID,
+		"sources", fmt.Sprint(s.sources()),
 		"max_goroutines", s.Config.MaxGoroutines,
+		"max_depth", s.Config.MaxDepth,
 	)
-	s.FailedCount = 0
-	s.SkippedCount = 0
+	s.FailedCount.Store(0)
+	s.SkippedCount.Store(0)
+	s.ConflictCount.Store(0)
 	startTime := time.Now()
 
+	// pick up renamed label choices once per full sync
+	s.labelsMu.Lock()
+	s.labelChoices = nil
+	s.labelsMu.Unlock()
+
+	// Remember where the Drive changes stand before listing, so the next incremental
+	// sync picks up anything modified while this one runs
+	var startPageToken string
+	if s.Config.Incremental {
+		token, err := s.GoogleClient.GetStartPageToken(ctx)
+		if err != nil {
+			s.Logger.Error("failed to get changes start page token", "error", err.Error())
+		}
+		startPageToken = token
+	}
+
+	// Traverse folders and send files to workers
+	var (
+		totalCount int32
+		complete   bool
+		failedMu   sync.Mutex
+		failedDocs []string
+	)
+	s.runWorkers(ctx, func(workerItems chan<- *WorkerItem) {
+		complete = s.walkFolders(ctx, workerItems, &totalCount, func(googleDocID string) {
+			failedMu.Lock()
+			defer failedMu.Unlock()
+			failedDocs = append(failedDocs, googleDocID)
+		})
+	})
+
+	// Specs are only known to be gone once every folder was listed, and Docs that failed
+	// to parse keep their spec until they are read again
+	if complete && ctx.Err() == nil {
+		query := s.DB.Where("synced_at < ?", startTime)
+		if len(failedDocs) > 0 {
+			query = query.Where("google_doc_id NOT IN ?", failedDocs)
+		}
+		deletedSpecs := query.Delete(&db.Spec{}).RowsAffected
+		s.Logger.Info("deleted old specs", "count", deletedSpecs)
+		if err := deleteOrphanedSpecData(s.DB); err != nil {
+			s.Logger.Error("failed to delete comments and permissions of old specs", "error", err.Error())
+		}
+	} else {
+		s.Logger.Warn("not all folders were listed, keeping specs that were not seen")
+	}
+
+	s.Logger.Info("specs synchronization completed",
+		"duration", time.Since(startTime).Seconds(),
+		"total_count", totalCount,
+		"failed_count", s.FailedCount.Load(),
+		"skipped_count", s.SkippedCount.Load(),
+		"conflict_count", s.ConflictCount.Load(),
+	)
+
+	if startPageToken != "" && ctx.Err() == nil {
+		if err := s.saveSyncState(startPageToken, startTime); err != nil {
+			s.Logger.Error("failed to save sync state", "error", err.Error())
+		}
+	}
+
+	return ctx.Err()
+}
+
+// deleteOrphanedSpecData removes the rows of specs that no longer exist: their reviewers,
+// comment activity, permissions, property conflicts, status changes and the links to
+// their authors and reviewers in the people table
+func deleteOrphanedSpecData(tx *gorm.DB) error {
+	specIDs := tx.Model(&db.Spec{}).Select("id")
+	for _, model := range []any{
+		&db.Reviewer{}, &db.SpecCommentActivity{}, &db.SpecCommenter{}, &db.SpecPermission{}, &db.SpecPropertyConflict{}, &db.SpecStatusChange{},
+		&db.SpecAuthor{}, &db.SpecReviewer{},
+	} {
+		if err := tx.Where("spec_id NOT IN (?)", specIDs).Delete(model).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runWorkers starts the worker pool and feeds it with the items sent by produce,
+// returning once all items have been processed
+func (s *SyncService) runWorkers(ctx context.Context, produce func(workerItems chan<- *WorkerItem)) {
 	workerItems := make(chan *WorkerItem, s.Config.MaxGoroutines)
 	var wg sync.WaitGroup
 
@@ -88,68 +259,154 @@ func (s *SyncService) SyncSpecs(ctx context.Context) error {
 		}(i)
 	}
 
-	// Process folders and send files to workers
-	folderChan := s.GoogleClient.GetSubFoldersChannel(ctx, s.Config.RootFolderID)
-	totalCount := int32(0)
 	go func() {
 		defer close(workerItems)
-		for folder := range folderChan {
+		produce(workerItems)
+	}()
+
+	// Wait for all workers to finish
+	wg.Wait()
+}
+
+// walkFolders traverses the folder trees of the sources breadth-first, sending every
+// Google Doc found to the workers. Shortcuts are resolved to their target Doc, and Docs
+// reachable through several parents, shortcuts or sources are only sent once. failed is
+// called with the Docs that could not be parsed. It reports whether every source and
+// folder was listed in full.
+func (s *SyncService) walkFolders(
+	ctx context.Context,
+	workerItems chan<- *WorkerItem,
+	totalCount *int32,
+	failed func(googleDocID string),
+) bool {
+	complete := true
+	var queue []folderItem
+	visitedFolders := map[string]bool{}
+	seenDocs := map[string]bool{}
+
+	for _, source := range s.sources() {
+		if visitedFolders[source.RootID()] {
+			continue
+		}
+		root, err := s.rootFolderItem(ctx, source)
+		if err != nil {
+			s.Logger.Error("failed to resolve sync source", "source", source.String(), "error", err.Error())
+			complete = false
+			continue
+		}
+		visitedFolders[source.RootID()] = true
+		queue = append(queue, root)
+	}
+
+	for len(queue) > 0 {
+		item := queue[0]
+		queue = queue[1:]
+
+		logger := s.Logger.With(
+			"source", item.Source.String(),
+			"folder_id", item.Folder.File.Id,
+			"folder_name", item.Folder.File.Name,
+			"team", item.Team,
+			"depth", item.Depth,
+		)
+		logger.Info("processing folder")
+
+		folderCount := 0
+		for child := range s.GoogleClient.GetFolderChildrenChannel(ctx, item.Folder.File.Id, item.Source.DriveID, s.Extractors.MimeTypes()...) {
 			if ctx.Err() != nil {
-				return
+				return false
 			}
 
-			logger := s.Logger.With("folder_id", folder.File.Id, "folder_name", folder.File.Name)
-
-			if folder.Err != nil {
-				logger.Error("failed to get subfolders", "error", folder.Err.Error())
+			if child.Err != nil {
+				logger.Error("failed to get folder children", "error", child.Err.Error())
+				complete = false
 				continue
 			}
 
-			subFolderFilesChan := s.GoogleClient.GetFilesInFolderChannel(ctx, folder.File.Id)
-			logger.Info("processing folder")
-
-			folderCount := 0
-			for file := range subFolderFilesChan {
-				if ctx.Err() != nil {
-					return
+			file := child.File
+			switch file.MimeType {
+			case google.MimeTypeFolder:
+				if visitedFolders[file.Id] {
+					continue
 				}
-
-				atomic.AddInt32(&totalCount, 1)
-				folderCount++
-
-				if file.Err != nil {
-					logger.Error("failed to get files", "error", file.Err.Error())
+				visitedFolders[file.Id] = true
+				if item.Depth+1 > s.Config.MaxDepth {
+					logger.Debug("skipping folder beyond max depth", "subfolder_name", file.Name)
 					continue
 				}
+				queue = append(queue, folderItem{
+					Folder: child,
+					Team:   s.folderTeam(file, item),
+					Depth:  item.Depth + 1,
+					Source: item.Source,
+				})
+				continue
 
-				workerItem := &WorkerItem{
-					File:         file,
-					ParentFolder: folder,
+			case google.MimeTypeShortcut:
+				resolved, err := s.resolveShortcut(ctx, file)
+				if err != nil {
+					logger.Error("failed to resolve shortcut", "shortcut_name", file.Name, "error", err.Error())
+					complete = false
+					continue
 				}
-
-				select {
-				case workerItems <- workerItem:
-				case <-ctx.Done():
-					return
+				if resolved == nil {
+					logger.Debug("skipping shortcut to unsupported file", "shortcut_name", file.Name)
+					continue
 				}
+				file = resolved
+			}
+
+			if seenDocs[file.Id] {
+				logger.Debug("skipping already seen document", "file_id", file.Id, "file_name", file.Name)
+				continue
 			}
+			seenDocs[file.Id] = true
 
-			logger.Info("folder processed", "folder_count", folderCount)
+			atomic.AddInt32(totalCount, 1)
+			folderCount++
+
+			workerItem := &WorkerItem{
+				File:         google.FileResult{File: file},
+				ParentFolder: item.Folder,
+				Team:         item.Team,
+				Reconcile:    true,
+				Done: func(err error) {
+					if err != nil {
+						failed(file.Id)
+					}
+				},
+			}
+
+			select {
+			case workerItems <- workerItem:
+			case <-ctx.Done():
+				return false
+			}
 		}
-	}()
 
-	// Wait for all workers to finish
-	wg.Wait()
+		logger.Info("folder processed", "folder_count", folderCount)
+	}
+	return complete
+}
 
-	deletedSpecs := s.DB.Exec("DELETE FROM specs WHERE synced_at < ?", startTime).RowsAffected
-	s.Logger.Info("deleted old specs", "count", deletedSpecs)
+// resolveShortcut returns the file a shortcut points to, or nil if the target is not of
+// a synced MIME type
+func (s *SyncService) resolveShortcut(ctx context.Context, shortcut *drive.File) (*drive.File, error) {
+	details := shortcut.ShortcutDetails
+	if details == nil || details.TargetId == "" {
+		return nil, fmt.Errorf("shortcut has no target")
+	}
+	if s.Extractors.For(details.TargetMimeType) == nil {
+		return nil, nil
+	}
 
-	s.Logger.Info("specs synchronization completed",
-		"duration", time.Since(startTime).Seconds(),
-		"total_count", totalCount,
-		"failed_count", s.FailedCount,
-		"skipped_count", s.SkippedCount,
-	)
+	target, err := s.GoogleClient.GetFile(ctx, details.TargetId)
+	if err != nil {
+		return nil, err
+	}
+	if s.Extractors.For(target.MimeType) == nil {
+		return nil, nil
+	}
 
-	return ctx.Err()
+	return target, nil
 }
@@ -0,0 +1,89 @@
+package specs
+
+import (
+	"context"
+	"testing"
+
+	"github.com/canonical/specs-v2.canonical.com/db"
+)
+
+func TestSyncSpecs(t *testing.T) {
+	conn := testDB(t)
+	server, client := testGoogle(t)
+	service := testSyncService(t, client, conn)
+	ctx := context.Background()
+
+	if err := service.SyncSpecs(ctx); err != nil {
+		t.Fatalf("SyncSpecs() error = %v", err)
+	}
+
+	stale := specByDocID(t, conn, fileByName(t, server, "EN002 - Stale drafting spec"))
+	if stale.ID != "EN002" {
+		t.Errorf("spec ID = %q, want EN002", stale.ID)
+	}
+	if stale.Team != "Engineering" {
+		t.Errorf("team = %q, want Engineering", stale.Team)
+	}
+	if stale.Status == nil || *stale.Status != "Drafting" {
+		t.Errorf("status = %v, want Drafting", valueOf(stale.Status))
+	}
+
+	var permissions int64
+	conn.Model(&db.SpecPermission{}).Where("spec_id = ?", stale.ID).Count(&permissions)
+	if permissions != 2 {
+		t.Errorf("permissions = %d, want 2", permissions)
+	}
+
+	var authors []db.SpecAuthor
+	conn.Where("spec_id = ?", stale.ID).Find(&authors)
+	if len(authors) != 1 || authors[0].PersonID != "john.smith@canonical.com" {
+		t.Errorf("authors = %+v, want john.smith@canonical.com", authors)
+	}
+
+	// the shortcut in Design leads to a Doc synced from Engineering
+	var shortcutTargets int64
+	conn.Model(&db.Spec{}).Where("google_doc_id = ?", "en001").Count(&shortcutTargets)
+	if shortcutTargets != 1 {
+		t.Errorf("specs of the shortcut target = %d, want 1", shortcutTargets)
+	}
+
+	// only Google Docs are synced without extractors, and shared drives are not sources
+	for _, name := range []string{"EN003 - Load test results.pdf", "EN004 - Word template.docx", "PL001 - Shared drive sources"} {
+		var count int64
+		conn.Model(&db.Spec{}).Where("google_doc_id = ?", fileByName(t, server, name)).Count(&count)
+		if count != 0 {
+			t.Errorf("%s was synced", name)
+		}
+	}
+}
+
+func TestSyncSpecsRemovesTrashedDocs(t *testing.T) {
+	conn := testDB(t)
+	server, client := testGoogle(t)
+	service := testSyncService(t, client, conn)
+	ctx := context.Background()
+
+	if err := service.SyncSpecs(ctx); err != nil {
+		t.Fatalf("SyncSpecs() error = %v", err)
+	}
+	docID := fileByName(t, server, "EN002 - Stale drafting spec")
+	spec := specByDocID(t, conn, docID)
+
+	server.Trash(docID)
+	if err := service.SyncSpecs(ctx); err != nil {
+		t.Fatalf("SyncSpecs() error = %v", err)
+	}
+
+	var count int64
+	conn.Model(&db.Spec{}).Where("google_doc_id = ?", docID).Count(&count)
+	if count != 0 {
+		t.Errorf("trashed spec was kept")
+	}
+	conn.Model(&db.SpecPermission{}).Where("spec_id = ?", spec.ID).Count(&count)
+	if count != 0 {
+		t.Errorf("permissions of the trashed spec were kept")
+	}
+
+	// the other specs are left in place
+	specByDocID(t, conn, "en001")
+}
@@ -0,0 +1,181 @@
+package specs
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/canonical/specs-v2.canonical.com/db"
+)
+
+// MetadataParser reads the metadata table of one version of the spec template
+type MetadataParser interface {
+	// Version is the template version recorded on the specs it parses
+	Version() string
+	// Detect scores how closely a table matches the template, from 0 when it does not
+	// match at all to 100 when it certainly does
+	Detect(table [][]string) int
+	Parse(table [][]string, spec *db.Spec)
+	// StatusCell returns the position of the status value in the table, ok is false
+	// when the table has none
+	StatusCell(table [][]string) (row, col int, ok bool)
+}
+
+// MetadataParserRegistry maps template versions to the parser of their metadata table
+type MetadataParserRegistry map[string]MetadataParser
+
+// MetadataParsers are the template versions recognized by the sync, the history and the
+// reject services. Register the parser of a new template and retire the versions no
+// longer in use before starting them.
+var MetadataParsers = MetadataParserRegistry{
+	TemplateVersionRowBased:    rowBasedParser{},
+	TemplateVersionColumnBased: columnBasedParser{},
+}
+
+// Register adds the parser of a template version, replacing any previous one
+func (r MetadataParserRegistry) Register(parser MetadataParser) {
+	r[parser.Version()] = parser
+}
+
+// Retire removes a template version, its tables are then read by the best remaining match
+func (r MetadataParserRegistry) Retire(version string) {
+	delete(r, version)
+}
+
+// Detect returns the parser scoring highest on a table, or nil if none matches it. Ties
+// go to the version sorting first, so the choice does not depend on map order.
+func (r MetadataParserRegistry) Detect(table [][]string) MetadataParser {
+	versions := make([]string, 0, len(r))
+	for version := range r {
+		versions = append(versions, version)
+	}
+	sort.Strings(versions)
+
+	var (
+		best      MetadataParser
+		bestScore int
+	)
+	for _, version := range versions {
+		if score := r[version].Detect(table); score > bestScore {
+			best, bestScore = r[version], score
+		}
+	}
+	return best
+}
+
+// rowBasedKeys are the keys of the row based template
+var rowBasedKeys = []string{"index", "title", "type", "status", "authors", "created"}
+
+// rowBasedParser reads the original design, where each row holds a key and its value:
+/*
+[
+	["authors", "user1@canonical,user2@canonical.com,user3@canonical"],
+	["created", "2021-09-13"],
+	["index", "SN114"]
+]
+*/
+// Any key-value table can be read this way, so it scores above zero for every table with
+// two cell rows and each known key raises the score. It is the fallback of tables no
+// other template recognizes.
+type rowBasedParser struct{}
+
+func (rowBasedParser) Version() string { return TemplateVersionRowBased }
+
+func (rowBasedParser) Detect(table [][]string) int {
+	score := 0
+	for _, row := range table {
+		if len(row) < 2 {
+			continue
+		}
+		if score == 0 {
+			score = 1
+		}
+		for _, key := range rowBasedKeys {
+			if strings.EqualFold(strings.TrimSpace(row[0]), key) {
+				score += 10
+				break
+			}
+		}
+	}
+	return min(score, 90)
+}
+
+func (rowBasedParser) Parse(table [][]string, spec *db.Spec) {
+	parseRowBasedMetadata(table, spec)
+}
+
+func (rowBasedParser) StatusCell(table [][]string) (int, int, bool) {
+	for i, row := range table {
+		if len(row) >= 2 && strings.EqualFold(strings.TrimSpace(row[0]), "status") {
+			return i, 1, true
+		}
+	}
+	return 0, 0, false
+}
+
+// columnBasedHeaders are the headers of the third row of the column based template
+var columnBasedHeaders = []string{"type", "author(s)", "status", "created"}
+
+// columnBasedParser reads the current design, where the third row holds the headers of
+// the values below them and the reviewers follow with their own header row:
+/*
+[
+	["Index", "PR001", "", ""],
+	["Title", "Specifications - Purpose and Guidance"],
+	["Type", "Author(s)", "Status", "Created"],
+	[
+		"Process",
+		"user1@canonical.com,user2@canonical.com,user3@canonical.com",
+		"Approved",
+		"Apr 22, 2021"
+	],
+	["", "Reviewer(s)", "Status", "Date"],
+	["", "user4@canonical.com", "Approved", "Aug 11, 2023"],
+	["", "user3@canonical.com", "Approved", "Aug 11, 2023"],
+	["", "user2@canonical.com", "Approved", "Aug 11, 2023"],
+	["", "user1@canonical.com", "Approved", "Jul 11, 2023"]
+]
+*/
+// A table with all the headers is certainly one, and a table missing one of them, e.g.
+// after a column was renamed, still outscores the row based design.
+type columnBasedParser struct{}
+
+func (columnBasedParser) Version() string { return TemplateVersionColumnBased }
+
+func (columnBasedParser) Detect(table [][]string) int {
+	if len(table) < 4 {
+		return 0
+	}
+
+	foundKeys := 0
+	for _, cell := range table[2] {
+		for _, expected := range columnBasedHeaders {
+			if strings.EqualFold(strings.TrimSpace(cell), expected) {
+				foundKeys++
+				break
+			}
+		}
+	}
+	switch {
+	case foundKeys >= len(columnBasedHeaders):
+		return 100
+	case foundKeys == len(columnBasedHeaders)-1:
+		return 60
+	}
+	return 0
+}
+
+func (columnBasedParser) Parse(table [][]string, spec *db.Spec) {
+	parseColumnBasedMetadata(table, spec)
+}
+
+func (columnBasedParser) StatusCell(table [][]string) (int, int, bool) {
+	if len(table) < 4 {
+		return 0, 0, false
+	}
+	for i, header := range table[2] {
+		if strings.EqualFold(strings.TrimSpace(header), "status") && i < len(table[3]) {
+			return 3, i, true
+		}
+	}
+	return 0, 0, false
+}
@@ -0,0 +1,51 @@
+package specs
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"path"
+	"strconv"
+
+	"github.com/canonical/specs-v2.canonical.com/db"
+	"google.golang.org/api/drive/v3"
+)
+
+// ThumbnailObjectKey returns the key of the thumbnail of a version of a file, e.g.
+// "thumbnails/1AbC/42"
+func ThumbnailObjectKey(prefix, googleDocID string, version int64) string {
+	return path.Join(prefix, googleDocID, strconv.FormatInt(version, 10))
+}
+
+// syncThumbnail caches the Drive thumbnail of a spec's file when its version changed
+// since the cached one, replacing the previous thumbnail. Files without a thumbnailLink,
+// such as Markdown files, have none.
+func (s *SyncService) syncThumbnail(ctx context.Context, logger *slog.Logger, specID string, file *drive.File, cachedKey string) error {
+	if s.Thumbnails == nil || file.ThumbnailLink == "" {
+		return nil
+	}
+	key := ThumbnailObjectKey(s.Config.ThumbnailPrefix, file.Id, file.Version)
+	if key == cachedKey {
+		return nil
+	}
+
+	image, err := s.GoogleClient.DownloadThumbnail(ctx, file.ThumbnailLink)
+	if err != nil {
+		return fmt.Errorf("failed to download thumbnail: %w", err)
+	}
+	if err := s.Thumbnails.PutObject(ctx, key, http.DetectContentType(image), image); err != nil {
+		return err
+	}
+	if err := s.DB.Model(&db.Spec{}).Where("id = ?", specID).Update("thumbnail_key", key).Error; err != nil {
+		return fmt.Errorf("failed to update thumbnail key: %w", err)
+	}
+
+	if cachedKey != "" {
+		if err := s.Thumbnails.DeleteObject(ctx, cachedKey); err != nil {
+			logger.Warn("failed to delete previous thumbnail", "error", err.Error())
+		}
+	}
+	logger.Debug("cached thumbnail", "object_key", key, "size", len(image))
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/canonical/specs-v2.canonical.com/google"
 	"google.golang.org/api/docs/v1"
 )
 
@@ -21,65 +22,36 @@ import (
 // cell's start index.
 const cellBoundaryOffset int64 = 2
 
-// cellCoordinates represents the position of a status cell in a
-// Google Doc table.
-type cellCoordinates struct {
-	Row int
-	Col int
-}
-
-// updateDocumentStatus updates the Google Doc to change status
+// updateDocumentStatus replaces the text of the status cell of a Google Doc
 func (r *RejectService) updateDocumentStatus(
 	ctx context.Context,
+	client google.Backend,
 	docID string,
-	coords *cellCoordinates,
+	cell *google.TableCell,
 	newStatus string,
 ) error {
-	doc, err := r.GoogleClient.DocsService.Documents.Get(docID).Context(ctx).Do()
-	if err != nil {
-		return fmt.Errorf("failed to fetch document: %v", err)
-	}
-
-	var table *docs.Table
-	for _, elem := range doc.Body.Content {
-		if elem.Table != nil {
-			table = elem.Table
-			break
-		}
-	}
-	if table == nil {
-		return fmt.Errorf("no metadata table found in document")
-	}
-
-	if coords.Row >= len(table.TableRows) {
-		return fmt.Errorf("row index %d out of bounds (max %d)", coords.Row, len(table.TableRows)-1)
-	}
-	row := table.TableRows[coords.Row]
-
-	if coords.Col >= len(row.TableCells) {
-		return fmt.Errorf("column index %d out of bounds (max %d)", coords.Col, len(row.TableCells)-1)
-	}
-	cell := row.TableCells[coords.Col]
-
-	if len(cell.Content) == 0 || cell.Content[0].Paragraph == nil || len(cell.Content[0].Paragraph.Elements) == 0 {
-		return fmt.Errorf("target cell is empty or malformed")
-	}
-
-	contentElements := cell.Content[0].Paragraph.Elements
-	cellStartIndex := contentElements[0].StartIndex
-	cellEndIndex := contentElements[len(contentElements)-1].EndIndex - 1
-	_, err = r.GoogleClient.DocsService.Documents.BatchUpdate(docID, &docs.BatchUpdateDocumentRequest{
-		Requests: []*docs.Request{{
+	var requests []*docs.Request
+	if cell.ContentEndIndex > cell.ContentStartIndex {
+		requests = append(requests, &docs.Request{
 			DeleteContentRange: &docs.DeleteContentRangeRequest{
-				Range: &docs.Range{StartIndex: cellStartIndex, EndIndex: cellEndIndex},
-			},
-		}, {
-			InsertText: &docs.InsertTextRequest{
-				Location: &docs.Location{Index: cellStartIndex},
-				Text:     newStatus,
+				Range: &docs.Range{
+					StartIndex: cell.ContentStartIndex,
+					EndIndex:   cell.ContentEndIndex,
+					TabId:      cell.TabID,
+				},
 			},
-		}},
-	}).Context(ctx).Do()
+		})
+	}
+	requests = append(requests, &docs.Request{
+		InsertText: &docs.InsertTextRequest{
+			Location: &docs.Location{Index: cell.ContentStartIndex, TabId: cell.TabID},
+			Text:     newStatus,
+		},
+	})
+
+	_, err := client.BatchUpdateDocument(ctx, docID, &docs.BatchUpdateDocumentRequest{
+		Requests: requests,
+	})
 	if err != nil {
 		return fmt.Errorf("failed to update status cell: %v", err)
 	}
@@ -87,45 +59,32 @@ func (r *RejectService) updateDocumentStatus(
 	return nil
 }
 
-// addRejectionNotice appends a rejection notice to the spec's changelog table
+// addRejectionNotice appends a rejection notice to the spec's changelog table, found in
+// whichever tab of the Doc holds it
 func (r *RejectService) addRejectionNotice(
 	ctx context.Context,
+	client google.Backend,
 	docID string,
 	cleanupID string,
 ) error {
-	doc, err := r.GoogleClient.DocsService.Documents.Get(docID).Context(ctx).Do()
+	doc, err := client.GetDocument(ctx, docID)
 	if err != nil {
 		return fmt.Errorf("failed to fetch updated document: %v", err)
 	}
 
-	// Find the changelog table (table after "Spec History and Changelog" heading)
-	var changelogTable *docs.Table
-	var changelogTableElementIndex int
-	var foundChangelogHeading bool
-	for i, element := range doc.Body.Content {
-		if element.Table != nil && foundChangelogHeading {
-			changelogTable = element.Table
-			changelogTableElementIndex = i
+	var (
+		changelogTable             *docs.Table
+		changelogTableElementIndex int
+		changelogTabID             string
+	)
+	for _, tab := range google.DocumentTabs(doc) {
+		changelogTable, changelogTableElementIndex = findChangelogTable(tab.Body)
+		if changelogTable != nil {
+			changelogTabID = tab.TabID
 			break
 		}
-		if element.Paragraph == nil {
-			continue
-		}
-
-		// Check if this is the changelog heading
-		for _, elem := range element.Paragraph.Elements {
-			if elem.TextRun == nil {
-				continue
-			}
-			text := strings.ToLower(strings.TrimSpace(elem.TextRun.Content))
-			if strings.Contains(text, "changelog") ||
-				strings.Contains(text, "history") {
-				foundChangelogHeading = true
-				break
-			}
-		}
 	}
-	if !foundChangelogHeading || changelogTable == nil {
+	if changelogTable == nil {
 		return fmt.Errorf("no 'Spec History and Changelog' table found")
 	}
 	if len(changelogTable.TableRows[0].TableCells) == 0 {
@@ -136,31 +95,32 @@ func (r *RejectService) addRejectionNotice(
 	rejectionRequests := []*docs.Request{{
 		InsertTableRow: &docs.InsertTableRowRequest{
 			TableCellLocation: &docs.TableCellLocation{
-				TableStartLocation: &docs.Location{Index: tableIndex},        // Use the table's start index
-				RowIndex:           int64(len(changelogTable.TableRows) - 1), // Last row index
-				ColumnIndex:        int64(0),                                 // Reference the first column
+				TableStartLocation: &docs.Location{Index: tableIndex, TabId: changelogTabID}, // Use the table's start index
+				RowIndex:           int64(len(changelogTable.TableRows) - 1),                 // Last row index
+				ColumnIndex:        int64(0),                                                 // Reference the first column
 			},
 			InsertBelow: true,
 		},
 	}}
-	_, err = r.GoogleClient.DocsService.Documents.BatchUpdate(docID, &docs.BatchUpdateDocumentRequest{
+	_, err = client.BatchUpdateDocument(ctx, docID, &docs.BatchUpdateDocumentRequest{
 		Requests: rejectionRequests,
-	}).Context(ctx).Do()
+	})
 	if err != nil {
 		return fmt.Errorf("failed to create new changelog row: %v", err)
 	}
 
 	// Refresh the document to get updated table
 	// Some tables have defaults for new rows which we need to overwrite
-	doc, err = r.GoogleClient.DocsService.Documents.Get(docID).Context(ctx).Do()
+	doc, err = client.GetDocument(ctx, docID)
 	if err != nil {
 		return fmt.Errorf("failed to fetch updated document: %v", err)
 	}
-	if len(doc.Body.Content) <= changelogTableElementIndex || doc.Body.Content[changelogTableElementIndex].Table == nil {
+	tab, ok := google.DocumentTabByID(doc, changelogTabID)
+	if !ok || len(tab.Body.Content) <= changelogTableElementIndex || tab.Body.Content[changelogTableElementIndex].Table == nil {
 		return fmt.Errorf("failed to locate updated changelog table at expected position")
 	}
 
-	changelogTable = doc.Body.Content[changelogTableElementIndex].Table
+	changelogTable = tab.Body.Content[changelogTableElementIndex].Table
 	headerColumns := map[string]int{
 		"author":  -1,
 		"status":  -1,
@@ -209,7 +169,11 @@ func (r *RejectService) addRejectionNotice(
 		if contentSize > 0 {
 			insertTextRequests = append(insertTextRequests, &docs.Request{
 				DeleteContentRange: &docs.DeleteContentRangeRequest{
-					Range: &docs.Range{StartIndex: cellStartIndex, EndIndex: cellStartIndex + contentSize},
+					Range: &docs.Range{
+						StartIndex: cellStartIndex,
+						EndIndex:   cellStartIndex + contentSize,
+						TabId:      changelogTabID,
+					},
 				},
 			})
 		}
@@ -218,7 +182,7 @@ func (r *RejectService) addRejectionNotice(
 		insertTextRequests = append(insertTextRequests, &docs.Request{
 			InsertText: &docs.InsertTextRequest{
 				Text:     content,
-				Location: &docs.Location{Index: cellStartIndex},
+				Location: &docs.Location{Index: cellStartIndex, TabId: changelogTabID},
 			},
 		})
 
@@ -226,9 +190,9 @@ func (r *RejectService) addRejectionNotice(
 		cellStartIndex += int64(len(content)) + cellBoundaryOffset
 	}
 
-	_, err = r.GoogleClient.DocsService.Documents.BatchUpdate(docID, &docs.BatchUpdateDocumentRequest{
+	_, err = client.BatchUpdateDocument(ctx, docID, &docs.BatchUpdateDocumentRequest{
 		Requests: insertTextRequests,
-	}).Context(ctx).Do()
+	})
 	if err != nil {
 		r.Logger.Error("failed to add rejection notice to changelog", "error", err.Error())
 		return fmt.Errorf("failed to insert text into new changelog row: %v", err)
@@ -238,10 +202,13 @@ func (r *RejectService) addRejectionNotice(
 }
 
 // addFallbackRejectionNotice creates a fallback rejection message with
-// red text when changelog table is not available.
+// red text when changelog table is not available, at the top of the tab
+// holding the metadata table.
 func (r *RejectService) addFallbackRejectionNotice(
 	ctx context.Context,
+	client google.Backend,
 	docID string,
+	tabID string,
 	cleanupID string,
 ) error {
 	rejectionMessage := fmt.Sprintf(
@@ -252,7 +219,7 @@ func (r *RejectService) addFallbackRejectionNotice(
 
 	rejectionRequests := []*docs.Request{{
 		InsertText: &docs.InsertTextRequest{
-			Location: &docs.Location{Index: 1},
+			Location: &docs.Location{Index: 1, TabId: tabID},
 			Text:     rejectionMessage + "\n\n",
 		},
 	}, {
@@ -260,6 +227,7 @@ func (r *RejectService) addFallbackRejectionNotice(
 			Range: &docs.Range{
 				StartIndex: 1,
 				EndIndex:   int64(len(rejectionMessage)) + 1,
+				TabId:      tabID,
 			},
 			TextStyle: &docs.TextStyle{
 				ForegroundColor: &docs.OptionalColor{
@@ -273,13 +241,41 @@ func (r *RejectService) addFallbackRejectionNotice(
 		},
 	}}
 
-	_, err := r.GoogleClient.DocsService.Documents.BatchUpdate(docID, &docs.BatchUpdateDocumentRequest{
+	_, err := client.BatchUpdateDocument(ctx, docID, &docs.BatchUpdateDocumentRequest{
 		Requests: rejectionRequests,
-	}).Context(ctx).Do()
+	})
 
 	return err
 }
 
+// findChangelogTable returns the first table following the "Spec History and Changelog"
+// heading of a body and its position in the body content, or nil if there is none
+func findChangelogTable(body *docs.Body) (*docs.Table, int) {
+	var foundChangelogHeading bool
+	for i, element := range body.Content {
+		if element.Table != nil && foundChangelogHeading {
+			return element.Table, i
+		}
+		if element.Paragraph == nil {
+			continue
+		}
+
+		// Check if this is the changelog heading
+		for _, elem := range element.Paragraph.Elements {
+			if elem.TextRun == nil {
+				continue
+			}
+			text := strings.ToLower(strings.TrimSpace(elem.TextRun.Content))
+			if strings.Contains(text, "changelog") ||
+				strings.Contains(text, "history") {
+				foundChangelogHeading = true
+				break
+			}
+		}
+	}
+	return nil, 0
+}
+
 // normalizeChangelogHeader extracts and normalizes text from a table
 // cell header.
 func normalizeChangelogHeader(cell *docs.TableCell) string {
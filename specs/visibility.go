@@ -0,0 +1,105 @@
+package specs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/canonical/specs-v2.canonical.com/db"
+	"gorm.io/gorm"
+)
+
+// Principal is a signed-in user and the groups they belong to
+type Principal struct {
+	Email  string
+	Groups []string
+}
+
+// GroupResolver returns the emails of the groups a user belongs to
+type GroupResolver interface {
+	Groups(ctx context.Context, email string) ([]string, error)
+}
+
+// StaticGroupResolver reads group memberships from a JSON file mapping group emails to
+// the emails of their members, such as {"platform@canonical.com": ["jane@canonical.com"]}.
+// Groups may be members of other groups.
+type StaticGroupResolver struct {
+	// groups maps member emails to the groups they are direct members of
+	groups map[string][]string
+}
+
+// NewStaticGroupResolver loads the group memberships of a JSON file
+func NewStaticGroupResolver(path string) (*StaticGroupResolver, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read groups file: %w", err)
+	}
+	var members map[string][]string
+	if err := json.Unmarshal(data, &members); err != nil {
+		return nil, fmt.Errorf("failed to parse groups file: %w", err)
+	}
+
+	groups := make(map[string][]string)
+	for group, groupMembers := range members {
+		for _, member := range groupMembers {
+			member = strings.ToLower(member)
+			groups[member] = append(groups[member], strings.ToLower(group))
+		}
+	}
+	return &StaticGroupResolver{groups: groups}, nil
+}
+
+// Groups returns the groups a user is a direct or nested member of
+func (r *StaticGroupResolver) Groups(_ context.Context, email string) ([]string, error) {
+	var result []string
+	seen := map[string]bool{}
+	pending := []string{strings.ToLower(email)}
+	for len(pending) > 0 {
+		member := pending[0]
+		pending = pending[1:]
+		for _, group := range r.groups[member] {
+			if seen[group] {
+				continue
+			}
+			seen[group] = true
+			result = append(result, group)
+			pending = append(pending, group)
+		}
+	}
+	return result, nil
+}
+
+// VisibleSpecIDs returns a subquery of the IDs of the specs a principal can open in
+// Drive: specs shared with anyone, with the principal, one of their groups or their
+// email domain. Specs without recorded permissions are not visible.
+func VisibleSpecIDs(tx *gorm.DB, principal Principal) *gorm.DB {
+	condition, args := grantedTo(principal)
+	return tx.Model(&db.SpecPermission{}).Select("spec_id").Where(condition, args...)
+}
+
+// VisibleArchiveIDs returns a subquery of the IDs of the archives a principal could
+// read when they were taken, whether or not the spec is still in Drive
+func VisibleArchiveIDs(tx *gorm.DB, principal Principal) *gorm.DB {
+	condition, args := grantedTo(principal)
+	return tx.Model(&db.SpecArchiveReader{}).Select("archive_id").Where(condition, args...)
+}
+
+// grantedTo returns the condition matching the grantees of the permissions reaching a
+// principal, on tables with the type, email and domain columns of spec_permissions
+func grantedTo(principal Principal) (string, []any) {
+	email := strings.ToLower(principal.Email)
+	_, domain, _ := strings.Cut(email, "@")
+	groups := make([]string, len(principal.Groups))
+	for i, group := range principal.Groups {
+		groups[i] = strings.ToLower(group)
+	}
+	if len(groups) == 0 {
+		// an empty list would render as an invalid IN ()
+		groups = []string{""}
+	}
+
+	return "type = 'anyone' OR (type = 'user' AND email = ?) OR (type = 'group' AND email IN ?) OR (type = 'domain' AND lower(domain) = ?)",
+		[]any{email, groups, domain}
+}
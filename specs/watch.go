@@ -0,0 +1,325 @@
+package specs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/canonical/specs-v2.canonical.com/db"
+	"github.com/canonical/specs-v2.canonical.com/google"
+	"github.com/google/uuid"
+	"google.golang.org/api/drive/v3"
+	"google.golang.org/api/googleapi"
+)
+
+type WatchConfig struct {
+	// Address receives the push notifications, i.e. the API server notifications endpoint
+	Address string
+	// Token is sent back by Drive with each notification to authenticate it
+	Token string
+	// ChannelTTL is the lifetime requested for new channels
+	ChannelTTL time.Duration
+	// RenewBefore is how long before their expiration channels are replaced
+	RenewBefore time.Duration
+	// RetryDelay is how long a queued Doc that failed waits before its first retry, the
+	// delay doubling with each failure
+	RetryDelay time.Duration
+	// MaxAttempts is the number of failures after which a queued Doc is dropped and left
+	// to the next full sync
+	MaxAttempts int
+}
+
+// RenewWatchChannels opens a Drive push notification channel for every indexed Doc,
+// plus one for the changes feed in incremental mode. Channels expiring within
+// RenewBefore are replaced, and channels of Docs that are no longer indexed are closed.
+//
+// Docs are watched one by one so a notification names the Doc to re-parse, while the
+// changes channel also catches Docs added to the tree.
+func (s *SyncService) RenewWatchChannels(ctx context.Context) error {
+	var channels []db.WatchChannel
+	if err := s.DB.Find(&channels).Error; err != nil {
+		return fmt.Errorf("failed to fetch watch channels: %w", err)
+	}
+
+	var docIDs []string
+	if err := s.DB.Model(&db.Spec{}).Distinct("google_doc_id").Pluck("google_doc_id", &docIDs).Error; err != nil {
+		return fmt.Errorf("failed to fetch indexed documents: %w", err)
+	}
+
+	indexed := make(map[string]bool, len(docIDs))
+	for _, docID := range docIDs {
+		indexed[docID] = true
+	}
+
+	renewAt := time.Now().Add(s.Config.Watch.RenewBefore)
+	current := map[string]bool{}
+	hasChangesChannel := false
+	var stale []db.WatchChannel
+	opened := 0
+
+	for _, channel := range channels {
+		keep := channel.Expiration.After(renewAt)
+		switch channel.Kind {
+		case db.WatchChannelKindFile:
+			keep = keep && indexed[channel.GoogleDocID]
+		case db.WatchChannelKindChanges:
+			keep = keep && s.Config.Incremental
+		}
+
+		if keep {
+			if channel.Kind == db.WatchChannelKindChanges {
+				hasChangesChannel = true
+			} else {
+				current[channel.GoogleDocID] = true
+			}
+			continue
+		}
+
+		stale = append(stale, channel)
+	}
+
+	for _, docID := range docIDs {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if current[docID] {
+			continue
+		}
+
+		req := s.watchRequest()
+		channel, err := s.GoogleClient.WatchFile(ctx, docID, req)
+		if err != nil {
+			s.Logger.Error("failed to watch document", "doc_id", docID, "error", err.Error())
+			continue
+		}
+		if err := s.saveWatchChannel(channel, db.WatchChannelKindFile, docID); err != nil {
+			return err
+		}
+		opened++
+	}
+
+	if s.Config.Incremental && !hasChangesChannel {
+		state, err := s.loadSyncState()
+		if err != nil {
+			return fmt.Errorf("failed to load sync state: %w", err)
+		}
+		if state != nil {
+			channel, err := s.GoogleClient.WatchChanges(ctx, state.PageToken, s.watchRequest())
+			if err != nil {
+				s.Logger.Error("failed to watch changes", "error", err.Error())
+			} else if err := s.saveWatchChannel(channel, db.WatchChannelKindChanges, ""); err != nil {
+				return err
+			} else {
+				opened++
+			}
+		}
+	}
+
+	// Close replaced channels once their successors are open, so no notification is missed
+	for _, channel := range stale {
+		s.closeWatchChannel(ctx, channel)
+	}
+
+	s.Logger.Info("watch channels renewed", "opened", opened, "closed", len(stale))
+	return nil
+}
+
+// ProcessQueue re-parses the Docs queued by push notifications. A notification from the
+// changes feed runs SyncChanges instead, which covers every changed Doc. Notifications
+// are removed from the queue once processed, and the ones that failed are retried with
+// an exponential backoff, up to Watch.MaxAttempts times. Processing a Doc twice, e.g.
+// from two sync processes, is harmless.
+func (s *SyncService) ProcessQueue(ctx context.Context) error {
+	var items []db.SyncQueueItem
+	if err := s.DB.Where("next_attempt_at <= ?", time.Now()).Order("created_at").Find(&items).Error; err != nil {
+		return fmt.Errorf("failed to read queued documents: %w", err)
+	}
+	if len(items) == 0 {
+		return nil
+	}
+
+	docIDs := []string{}
+	itemsByDoc := map[string][]db.SyncQueueItem{}
+	for _, item := range items {
+		if item.GoogleDocID == "" && s.Config.Incremental {
+			if err := s.SyncChanges(ctx); err != nil {
+				if ctx.Err() == nil {
+					return errors.Join(err, s.retry(items, err))
+				}
+				return err
+			}
+			// the changes feed covered the queued Docs too
+			return s.dequeue(queueItemIDs(items)...)
+		}
+		if _, ok := itemsByDoc[item.GoogleDocID]; !ok && item.GoogleDocID != "" {
+			docIDs = append(docIDs, item.GoogleDocID)
+		}
+		itemsByDoc[item.GoogleDocID] = append(itemsByDoc[item.GoogleDocID], item)
+	}
+	// changes feed notifications are of no use without the incremental sync
+	if err := s.dequeue(queueItemIDs(itemsByDoc[""])...); err != nil {
+		return err
+	}
+
+	s.Logger.Info("processing queued documents", "count", len(docIDs))
+	locations := map[string]*folderLocation{}
+
+	var (
+		mu        sync.Mutex
+		processed []string
+		failed    = map[string]error{}
+	)
+	done := func(docID string, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			failed[docID] = err
+			return
+		}
+		processed = append(processed, queueItemIDs(itemsByDoc[docID])...)
+	}
+
+	s.runWorkers(ctx, func(workerItems chan<- *WorkerItem) {
+		for _, docID := range docIDs {
+			logger := s.Logger.With("doc_id", docID)
+
+			file, err := s.GoogleClient.GetFile(ctx, docID)
+			var apiErr *googleapi.Error
+			if errors.As(err, &apiErr) && apiErr.Code == http.StatusNotFound {
+				file, err = &drive.File{Id: docID, Trashed: true}, nil
+			}
+			if err != nil {
+				logger.Error("failed to fetch queued document", "error", err.Error())
+				done(docID, err)
+				continue
+			}
+			if file.Trashed {
+				if _, err := s.deleteSpecsByGoogleDocID(docID); err != nil {
+					logger.Error("failed to delete trashed spec", "error", err.Error())
+					done(docID, err)
+					continue
+				}
+				done(docID, nil)
+				continue
+			}
+
+			item, err := s.workerItemFor(ctx, file, file, locations)
+			if err != nil {
+				logger.Error("failed to locate queued document", "error", err.Error())
+				done(docID, err)
+				continue
+			}
+			// Docs outside the synced folders are not indexed
+			if item == nil {
+				done(docID, nil)
+				continue
+			}
+			item.Done = func(err error) {
+				done(docID, err)
+			}
+
+			select {
+			case workerItems <- item:
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+
+	if err := s.dequeue(processed...); err != nil {
+		return err
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	for docID, err := range failed {
+		if err := s.retry(itemsByDoc[docID], err); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// retry postpones notifications that failed to be processed, doubling the delay with
+// each attempt, and drops the ones that failed Watch.MaxAttempts times. Their Docs are
+// parsed again by the next full sync.
+func (s *SyncService) retry(items []db.SyncQueueItem, cause error) error {
+	var dropped []string
+	for _, item := range items {
+		item.Attempts++
+		if item.Attempts >= s.Config.Watch.MaxAttempts {
+			s.Logger.Warn("dropping queued document after repeated failures",
+				"doc_id", item.GoogleDocID, "attempts", item.Attempts, "error", cause.Error())
+			dropped = append(dropped, item.ID)
+			continue
+		}
+
+		delay := s.Config.Watch.RetryDelay << (item.Attempts - 1)
+		err := s.DB.Model(&db.SyncQueueItem{}).Where("id = ?", item.ID).Updates(map[string]any{
+			"attempts":        item.Attempts,
+			"next_attempt_at": time.Now().Add(delay),
+		}).Error
+		if err != nil {
+			return fmt.Errorf("failed to postpone queued document: %w", err)
+		}
+	}
+	return s.dequeue(dropped...)
+}
+
+// dequeue removes processed notifications from the queue
+func (s *SyncService) dequeue(ids ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if err := s.DB.Where("id IN ?", ids).Delete(&db.SyncQueueItem{}).Error; err != nil {
+		return fmt.Errorf("failed to dequeue documents: %w", err)
+	}
+	return nil
+}
+
+func queueItemIDs(items []db.SyncQueueItem) []string {
+	ids := make([]string, len(items))
+	for i, item := range items {
+		ids[i] = item.ID
+	}
+	return ids
+}
+
+func (s *SyncService) watchRequest() google.WatchRequest {
+	return google.WatchRequest{
+		ChannelID:  uuid.NewString(),
+		Address:    s.Config.Watch.Address,
+		Token:      s.Config.Watch.Token,
+		Expiration: time.Now().Add(s.Config.Watch.ChannelTTL),
+	}
+}
+
+func (s *SyncService) saveWatchChannel(channel *drive.Channel, kind, docID string) error {
+	record := db.WatchChannel{
+		ID:          channel.Id,
+		ResourceID:  channel.ResourceId,
+		Kind:        kind,
+		GoogleDocID: docID,
+		Expiration:  time.UnixMilli(channel.Expiration),
+	}
+	if err := s.DB.Create(&record).Error; err != nil {
+		return fmt.Errorf("failed to save watch channel: %w", err)
+	}
+	return nil
+}
+
+// closeWatchChannel stops a channel and forgets it. Stopping is best effort as
+// expired channels are already closed by Drive.
+func (s *SyncService) closeWatchChannel(ctx context.Context, channel db.WatchChannel) {
+	if channel.Expiration.After(time.Now()) {
+		if err := s.GoogleClient.StopChannel(ctx, channel.ID, channel.ResourceID); err != nil {
+			s.Logger.Debug("failed to stop watch channel", "channel_id", channel.ID, "error", err.Error())
+		}
+	}
+	if err := s.DB.Delete(&db.WatchChannel{}, "id = ?", channel.ID).Error; err != nil {
+		s.Logger.Error("failed to delete watch channel", "channel_id", channel.ID, "error", err.Error())
+	}
+}
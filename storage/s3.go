@@ -0,0 +1,141 @@
+// Package storage stores files in S3-compatible object storage such as AWS S3, Ceph
+// or MinIO
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// S3Config configures an S3Client
+type S3Config struct {
+	// Endpoint is the URL of the S3 API, e.g. "http://localhost:9000" for MinIO. It
+	// defaults to the AWS endpoint of the region.
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	// PathStyle addresses the bucket in the path instead of the host name, as MinIO and
+	// most S3-compatible servers expect
+	PathStyle bool
+	// HTTPClient sends the requests, http.DefaultClient when nil
+	HTTPClient *http.Client
+}
+
+// S3Client reads and writes the objects of a bucket, signing requests with AWS
+// Signature Version 4
+type S3Client struct {
+	config   S3Config
+	endpoint *url.URL
+	client   *http.Client
+}
+
+// NewS3Client creates a client for the bucket of config
+func NewS3Client(config S3Config) (*S3Client, error) {
+	if config.Bucket == "" {
+		return nil, fmt.Errorf("s3 bucket is required")
+	}
+	if config.Region == "" {
+		config.Region = "us-east-1"
+	}
+	if config.Endpoint == "" {
+		config.Endpoint = "https://s3." + config.Region + ".amazonaws.com"
+	}
+	endpoint, err := url.Parse(config.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse s3 endpoint: %w", err)
+	}
+
+	client := config.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &S3Client{config: config, endpoint: endpoint, client: client}, nil
+}
+
+// PutObject uploads an object, replacing any object with the same key
+func (c *S3Client) PutObject(ctx context.Context, key, contentType string, body []byte) error {
+	req, err := c.newRequest(ctx, http.MethodPut, key, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := c.do(req, body)
+	if err != nil {
+		return fmt.Errorf("failed to put object %s: %w", key, err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// GetObject downloads an object, the caller must close the returned body
+func (c *S3Client) GetObject(ctx context.Context, key string) (io.ReadCloser, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.do(req, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object %s: %w", key, err)
+	}
+	return resp.Body, nil
+}
+
+// DeleteObject removes an object, deleting a missing object is not an error
+func (c *S3Client) DeleteObject(ctx context.Context, key string) error {
+	req, err := c.newRequest(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.do(req, nil)
+	if err != nil {
+		return fmt.Errorf("failed to delete object %s: %w", key, err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// newRequest builds a request on an object in the bucket
+func (c *S3Client) newRequest(ctx context.Context, method, key string, body []byte) (*http.Request, error) {
+	u := *c.endpoint
+	path := "/" + uriEncode(key, false)
+	if c.config.PathStyle {
+		path = "/" + uriEncode(c.config.Bucket, true) + path
+	} else {
+		u.Host = c.config.Bucket + "." + u.Host
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/")
+	u.RawPath = u.Path + path
+	u.Path, _ = url.PathUnescape(u.RawPath)
+
+	return http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+}
+
+// do signs and sends a request, failing on any status other than 2xx
+func (c *S3Client) do(req *http.Request, body []byte) (*http.Response, error) {
+	payloadHash := sha256.Sum256(body)
+	signV4(req, hex.EncodeToString(payloadHash[:]), c.config.AccessKey, c.config.SecretKey, c.config.Region, time.Now())
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("unexpected status %s: %s", resp.Status, strings.TrimSpace(string(message)))
+	}
+	return resp, nil
+}
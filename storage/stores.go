@@ -0,0 +1,39 @@
+package storage
+
+import (
+	"fmt"
+
+	"github.com/canonical/specs-v2.canonical.com/config"
+	"github.com/canonical/specs-v2.canonical.com/specs"
+)
+
+// NewObjectStores returns the stores of the spec archives and the thumbnails, nil when
+// disabled. Both share the S3 bucket when one is configured.
+func NewObjectStores(c *config.Config) (archive, thumbnails specs.ObjectStore, err error) {
+	var s3Client *S3Client
+	if c.IsArchiveEnabled() {
+		s3Client, err = NewS3Client(S3Config{
+			Endpoint:  c.S3Endpoint,
+			Region:    c.S3Region,
+			Bucket:    c.S3Bucket,
+			AccessKey: c.S3AccessKey,
+			SecretKey: c.S3SecretKey,
+			PathStyle: c.S3AddressingStyle == "path",
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		archive = s3Client
+	}
+
+	switch c.ThumbnailStorage {
+	case "disk":
+		thumbnails = &DiskStore{Dir: c.ThumbnailCacheDir}
+	case "s3":
+		if s3Client == nil {
+			return nil, nil, fmt.Errorf("thumbnail storage s3 requires S3_BUCKET")
+		}
+		thumbnails = s3Client
+	}
+	return archive, thumbnails, nil
+}
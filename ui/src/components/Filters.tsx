@@ -6,12 +6,14 @@ import {
 } from "@canonical/react-components";
 import { useFormik } from "formik";
 import { useEffect } from "react";
+import type { Person } from "../generated/types";
 import type { UserOptions } from "../hooks/useURLState";
 import { SPEC_STATUSES, SPEC_TYPES } from "../pages/Specs";
+import { personLabel } from "../utils";
 
 type FiltersProps = {
-  authors: string[];
-  reviewers: string[];
+  authors: Person[];
+  reviewers: Person[];
   teams: string[];
   userOptions: UserOptions;
   setUserOptions: (options: UserOptions) => void;
@@ -99,7 +101,10 @@ const Filters = ({
         searchable="always"
         options={[
           { value: "", label: "All authors" },
-          ...authors.map((author) => ({ label: author, value: author })),
+          ...authors.map((author) => ({
+            label: personLabel(author),
+            value: author.id,
+          })),
         ]}
         onChange={(value) => formik.setFieldValue("author", value)}
       />
@@ -111,7 +116,10 @@ const Filters = ({
         searchable="always"
         options={[
           { value: "", label: "All reviewers" },
-          ...reviewers.map((reviewer) => ({ label: reviewer, value: reviewer })),
+          ...reviewers.map((reviewer) => ({
+            label: personLabel(reviewer),
+            value: reviewer.id,
+          })),
         ]}
         onChange={(value) => formik.setFieldValue("reviewer", value)}
       />
@@ -123,6 +131,8 @@ const Filters = ({
         options={[
           { value: "updated_at", label: "Last modified" },
           { value: "created_at", label: "Create date" },
+          { value: "declared_created_at", label: "Declared create date" },
+          { value: "last_reviewed_at", label: "Last review" },
           { value: "title", label: "Name" },
           { value: "id", label: "Spec index" },
         ]}
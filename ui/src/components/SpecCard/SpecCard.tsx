@@ -4,6 +4,14 @@ import type { Spec } from "../../generated/types";
 import { SpecPreviewSidePanel } from "../SpecPreviewSidePanel";
 import "./styles.scss";
 
+// Labels of the specs that are not Google Docs
+const sourceFormatLabels: Record<string, string> = {
+  google_sheet: "Sheet",
+  docx: "Word",
+  markdown: "Markdown",
+  pdf: "PDF",
+};
+
 type SpecCardProps = {
   spec: Spec;
 };
@@ -35,6 +43,13 @@ const SpecCard = ({ spec }: SpecCardProps) => {
                   <li className="p-inline-list__item">{spec.id}</li>
                   <li className="p-inline-list__item">{spec.team}</li>
                   <li className="p-inline-list__item">{spec.spec_type}</li>
+                  {spec.source_format &&
+                    spec.source_format !== "google_doc" && (
+                      <li className="p-inline-list__item">
+                        {sourceFormatLabels[spec.source_format] ??
+                          spec.source_format}
+                      </li>
+                    )}
                 </ul>
               </small>
               <div
@@ -77,6 +92,14 @@ const SpecCard = ({ spec }: SpecCardProps) => {
             <small>
               <em>{spec.authors.join(", ")}</em>
             </small>
+            {spec.thumbnail_url && (
+              <img
+                className="spec-card__thumbnail"
+                src={spec.thumbnail_url}
+                alt=""
+                loading="lazy"
+              />
+            )}
           </div>
           <div className="spec-card__footer p-card__inner">
             <em className="u-align--right">{lastEdited}</em>
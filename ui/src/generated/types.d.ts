@@ -1,5 +1,42 @@
 // Code generated by tygo. DO NOT EDIT.
 
+//////////
+// source: admin.go
+
+/**
+ * SharingFinding is a spec shared outside the company or its owning team
+ */
+export interface SharingFinding {
+  spec_id: string;
+  title: string;
+  team: string;
+  url: string;
+  sharing_level: string;
+  external: string[];
+  outside_team: string[];
+}
+export interface SharingReportResponse {
+  findings: SharingFinding[];
+}
+
+//////////
+// source: archives.go
+
+/**
+ * SpecArchive is a PDF snapshot of a spec taken when it reached a terminal status
+ */
+export interface SpecArchive {
+  id: string;
+  status: string;
+  size: number /* int64 */;
+  google_doc_updated_at: string /* RFC3339 */;
+  archived_at: string /* RFC3339 */;
+}
+export interface SpecArchivesResponse {
+  spec_id: string;
+  archives: SpecArchive[];
+}
+
 //////////
 // source: google_oauth2.go
 
@@ -33,9 +70,46 @@ export interface Spec {
   google_doc_url: string;
   google_doc_created_at: string /* RFC3339 */;
   google_doc_updated_at: string /* RFC3339 */;
+  source_format: string;
+  template_version: string;
+  sharing_level: string;
   created_at: string /* RFC3339 */;
   updated_at: string /* RFC3339 */;
   synced_at: string /* RFC3339 */;
+  /**
+   * ThumbnailURL serves the Drive thumbnail of the spec, empty without one
+   */
+  thumbnail_url: string;
+  /**
+   * Comment activity on the spec's file, LastCommentAt is null without comments
+   */
+  open_comments: number /* int */;
+  resolved_comments: number /* int */;
+  last_comment_at?: string /* RFC3339 */;
+  commenters: string[];
+  /**
+   * DeclaredCreatedAt is the creation date written in the metadata table, often earlier
+   * than GoogleDocCreatedAt for Docs copied from a template. It is null when missing.
+   */
+  declared_created_at?: string /* RFC3339 */;
+  reviewers: Reviewer[];
+}
+/**
+ * Reviewer is a reviewer of a spec, ReviewedAt is null when the table gives no date
+ */
+export interface Reviewer {
+  name: string;
+  email: string;
+  status: string;
+  reviewed_at?: string /* RFC3339 */;
+}
+/**
+ * Person is an author or reviewer, Email is empty for the people known by name only
+ */
+export interface Person {
+  id: string;
+  name: string;
+  email: string;
 }
 export interface ListSpecsResponse {
   total: number /* int64 */;
@@ -43,3 +117,51 @@ export interface ListSpecsResponse {
   limit: number /* int32 */;
   offset: number /* int32 */;
 }
+/**
+ * SpecStatusPeriod is a period during which a spec had a status. EndedAt is null for
+ * the current status.
+ */
+export interface SpecStatusPeriod {
+  status: string;
+  started_at: string /* RFC3339 */;
+  ended_at?: string /* RFC3339 */;
+  revision_id: string;
+  changed_by: string;
+}
+export interface SpecHistoryResponse {
+  spec_id: string;
+  history: SpecStatusPeriod[];
+}
+/**
+ * SpecStatusChange is a status change of a spec and its likely author. Actor is empty
+ * when the author is unknown, and Automated is set for changes made by the reject job or
+ * another automation account.
+ */
+export interface SpecStatusChange {
+  from_status: string;
+  to_status: string;
+  changed_at: string /* RFC3339 */;
+  actor: string;
+  actor_name: string;
+  automated: boolean;
+}
+export interface SpecStatusChangesResponse {
+  spec_id: string;
+  changes: SpecStatusChange[];
+}
+/**
+ * SpecPropertyConflict is a value the metadata table of a spec disagrees with the app
+ * properties of its file on. The table wins, so Property is the value it replaced, last
+ * written by LastAction when an automation set it.
+ */
+export interface SpecPropertyConflict {
+  key: string;
+  table: string;
+  property: string;
+  last_action: string;
+  detected_at: string /* RFC3339 */;
+}
+export interface SpecPropertyConflictsResponse {
+  spec_id: string;
+  conflicts: SpecPropertyConflict[];
+}
@@ -10,7 +10,7 @@ import qs from "qs";
 import InfiniteScroll from "react-infinite-scroll-component";
 import Filters from "../components/Filters";
 import { SpecCard } from "../components/SpecCard";
-import type { ListSpecsResponse } from "../generated/types";
+import type { ListSpecsResponse, Person } from "../generated/types";
 import useURLState from "../hooks/useURLState";
 import { sortedSet } from "../utils";
 
@@ -70,8 +70,8 @@ function Specs() {
   const { data: authorsData } = useQuery({
     queryKey: ["authors"],
     queryFn: async () => {
-      const res = await fetch("/api/specs/authors");
-      return res.json() as Promise<string[]>;
+      const res = await fetch("/api/people/authors");
+      return res.json() as Promise<Person[]>;
     },
     refetchOnWindowFocus: false,
     refetchOnMount: false,
@@ -80,8 +80,8 @@ function Specs() {
   const { data: reviewersData } = useQuery({
     queryKey: ["reviewers"],
     queryFn: async () => {
-      const res = await fetch("/api/specs/reviewers");
-      return res.json() as Promise<string[]>;
+      const res = await fetch("/api/people/reviewers");
+      return res.json() as Promise<Person[]>;
     },
     refetchOnWindowFocus: false,
     refetchOnMount: false,
@@ -154,9 +154,9 @@ function Specs() {
         </div>
         <div className="l-fluid-breakout__aside sticky-sidebar">
           <Filters
-            authors={sortedSet(new Set(authors))}
+            authors={authors}
             teams={sortedSet(new Set(teams))}
-            reviewers={sortedSet(new Set(reviewers))}
+            reviewers={reviewers}
             userOptions={userOptions}
             setUserOptions={setUserOptions}
           />
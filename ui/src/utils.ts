@@ -1,3 +1,5 @@
+import type { Person } from "./generated/types";
+
 /**
  * Sort elements in set in alphabetical order and eliminate empty values.
  * @param elements list of elements
@@ -10,3 +12,13 @@ export function sortedSet(elements: Set<string>): string[] {
   }
   return sortedElements;
 }
+
+/**
+ * Label a person with their email, so namesakes can be told apart.
+ * @param person author or reviewer
+ */
+export function personLabel(person: Person): string {
+  return person.email && person.email !== person.name
+    ? `${person.name} (${person.email})`
+    : person.name;
+}